@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/pack"
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyDir    string
+	applyFormat string
+	applyDiff   bool
+	applyCheck  bool
+	applyForce  bool
+	applyQuiet  bool
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply <pack>",
+	Short: "Apply an edited pack to the working tree",
+	Long: `Compare the files in a pack with the working tree and write the ones
+that changed. The pack may be a full pack returned by a model or a partial
+one holding only the edited files; files it does not mention are left alone.
+
+Each file's recorded SHA-256 is its base. A file that changed on disk since
+the pack was made is a conflict, and nothing is written while conflicts
+remain unless --force is given.
+
+Examples:
+  codeecho apply response.xml --check      # Report changes and conflicts
+  codeecho apply response.xml --diff       # Print a unified diff
+  codeecho apply response.xml --diff | git apply
+  codeecho apply response.xml              # Write the changes`,
+	Args: cobra.ExactArgs(1),
+	RunE: runApply,
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringVarP(&applyDir, "dir", "C", ".", "Working tree to apply the pack to")
+	applyCmd.Flags().StringVar(&applyFormat, "format", "", "Pack format: xml, claude-xml, json, jsonl, markdown (default: detect)")
+	applyCmd.Flags().BoolVar(&applyDiff, "diff", false, "Print a unified diff instead of writing")
+	applyCmd.Flags().BoolVar(&applyCheck, "check", false, "Report changes and conflicts without writing; fail on conflicts")
+	applyCmd.Flags().BoolVar(&applyForce, "force", false, "Overwrite files that conflict")
+	applyCmd.Flags().BoolVarP(&applyQuiet, "quiet", "q", false, "Only print conflicts and skipped files")
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	// Arguments are valid by now; errors below are not usage mistakes
+	cmd.SilenceUsage = true
+
+	var p *pack.Pack
+	var err error
+	if applyFormat != "" {
+		p, err = readPackAs(args[0], applyFormat)
+	} else {
+		p, err = pack.Read(args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	currentCommit := ""
+	if git, _ := scanner.LoadGitMetadata(applyDir); git != nil {
+		currentCommit = git.CommitHash
+	}
+
+	plan, err := pack.PlanApply(p, pack.ApplyOptions{
+		Dir:           applyDir,
+		CurrentCommit: currentCommit,
+		Force:         applyForce,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Why: With --diff stdout is the patch, so the report goes to stderr
+	// and the output can be piped straight into git apply
+	report := os.Stdout
+	if applyDiff {
+		report = os.Stderr
+		fmt.Print(plan.Diff())
+	}
+	printApplyPlan(report, plan)
+
+	if len(plan.Conflicts) > 0 && !applyForce {
+		return fmt.Errorf("%d conflict(s); nothing written (use --force to overwrite)", len(plan.Conflicts))
+	}
+	if applyDiff || applyCheck {
+		return nil
+	}
+
+	if err := plan.Write(); err != nil {
+		return err
+	}
+	fmt.Fprintf(report, "\n✅ Applied %d change(s) to %s\n", len(plan.Changes), applyDir)
+	return nil
+}
+
+func printApplyPlan(out *os.File, plan *pack.ApplyPlan) {
+	if plan.BaseCommit != "" {
+		fmt.Fprintf(out, "⚠️  Pack was made at commit %s; the working tree is at %s\n",
+			plan.BaseCommit, plan.CurrentCommit)
+	}
+
+	if !applyQuiet {
+		for _, change := range plan.Changes {
+			fmt.Fprintf(out, "  %s %s\n", change.Kind, change.Path)
+		}
+	}
+	if len(plan.Conflicts) > 0 {
+		fmt.Fprintf(out, "\n⚠️  %d conflict(s):\n", len(plan.Conflicts))
+		for _, entry := range plan.Conflicts {
+			fmt.Fprintf(out, "  %s (%s)\n", entry.Path, entry.Reason)
+		}
+	}
+	if len(plan.Skipped) > 0 {
+		fmt.Fprintf(out, "\n⚠️  Skipped %d file(s):\n", len(plan.Skipped))
+		for _, entry := range plan.Skipped {
+			fmt.Fprintf(out, "  %s (%s)\n", entry.Path, entry.Reason)
+		}
+	}
+
+	fmt.Fprintf(out, "\n%d to change, %d unchanged, %d conflict(s), %d skipped\n",
+		len(plan.Changes), len(plan.Unchanged), len(plan.Conflicts), len(plan.Skipped))
+}
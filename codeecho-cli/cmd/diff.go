@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/pack"
+	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFormat          string
+	diffOutputFile      string
+	diffNoPatch         bool
+	diffRenameThreshold int
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <old-pack> <new-pack>",
+	Short: "Compare two packs",
+	Long: `Report what changed between two packs, e.g. the snapshots of two releases:
+added, removed, modified and renamed files, language, size and token
+deltas, and unified diffs of changed text files.
+
+Renames are detected by content similarity. Packs may be in any format
+unpack reads (xml, claude-xml, json, jsonl, markdown), and the two packs
+need not share a format.
+
+Examples:
+  codeecho diff v1.0.json v1.1.json
+  codeecho diff v1.0.xml v1.1.xml --format markdown -o CHANGES.md
+  codeecho diff old.json new.json --format json --no-patch`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&diffFormat, "format", "f", "text", "Report format: text, json, markdown")
+	diffCmd.Flags().StringVarP(&diffOutputFile, "output", "o", "", "Output file (default: stdout)")
+	diffCmd.Flags().BoolVar(&diffNoPatch, "no-patch", false, "Leave out per-file unified diffs")
+	diffCmd.Flags().IntVar(&diffRenameThreshold, "rename-threshold", pack.DefaultRenameThreshold, "Similarity in percent for a removed and an added file to count as a rename")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	var render func(io.Writer, *pack.Comparison) error
+	switch diffFormat {
+	case "text":
+		render = writeComparisonText
+	case "json":
+		render = writeComparisonJSON
+	case "markdown", "md":
+		render = writeComparisonMarkdown
+	default:
+		return fmt.Errorf("invalid --format: %q (use text, json or markdown)", diffFormat)
+	}
+	if diffRenameThreshold < 0 || diffRenameThreshold > 100 {
+		return fmt.Errorf("--rename-threshold must be between 0 and 100")
+	}
+	cmd.SilenceUsage = true
+
+	oldPack, err := pack.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	newPack, err := pack.Read(args[1])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[1], err)
+	}
+
+	comparison := pack.Compare(oldPack, newPack, pack.CompareOptions{
+		RenameThreshold: diffRenameThreshold,
+		Diffs:           !diffNoPatch,
+	})
+	// Name each side after its pack file when the pack does not record a path
+	if comparison.Old.Path == "" {
+		comparison.Old.Path = args[0]
+	}
+	if comparison.New.Path == "" {
+		comparison.New.Path = args[1]
+	}
+
+	out := io.Writer(os.Stdout)
+	if diffOutputFile != "" {
+		file, err := os.Create(diffOutputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	return render(out, comparison)
+}
+
+// statusLetters are the one-letter codes git uses for each status
+var statusLetters = map[string]string{
+	pack.StatusAdded:    "A",
+	pack.StatusRemoved:  "D",
+	pack.StatusModified: "M",
+	pack.StatusRenamed:  "R",
+}
+
+func writeComparisonJSON(w io.Writer, c *pack.Comparison) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+func writeComparisonText(w io.Writer, c *pack.Comparison) error {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Comparing %s\n       to %s\n\n", describeSide(c.Old), describeSide(c.New)))
+	b.WriteString(fmt.Sprintf("Files:  %d → %d (%s)\n", c.Old.Files, c.New.Files, signed(int64(c.New.Files-c.Old.Files))))
+	b.WriteString(fmt.Sprintf("Size:   %s → %s (%s)\n", utils.FormatBytes(c.Old.Size), utils.FormatBytes(c.New.Size), signedBytes(c.New.Size-c.Old.Size)))
+	b.WriteString(fmt.Sprintf("Tokens: %d → %d (%s)\n", c.Old.Tokens, c.New.Tokens, signed(int64(c.New.Tokens-c.Old.Tokens))))
+
+	if len(c.Files) == 0 {
+		b.WriteString("\nNo file changes\n")
+	} else {
+		b.WriteString(fmt.Sprintf("\nChanged files (%d, %d unchanged):\n", len(c.Files), c.Unchanged))
+		for _, change := range c.Files {
+			b.WriteString(fmt.Sprintf("  %s  %-50s %s\n", statusLetters[change.Status], changeName(change), changeStat(change)))
+		}
+	}
+
+	if len(c.Languages) > 0 {
+		b.WriteString("\nLanguages:\n")
+		for _, delta := range c.Languages {
+			b.WriteString(fmt.Sprintf("  %-20s %d → %d files  %s  %s tokens\n", delta.Language,
+				delta.OldFiles, delta.NewFiles, signedBytes(delta.NewSize-delta.OldSize),
+				signed(int64(delta.NewTokens-delta.OldTokens))))
+		}
+	}
+
+	for _, change := range c.Files {
+		if change.Diff != "" {
+			b.WriteString("\n")
+			b.WriteString(change.Diff)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeComparisonMarkdown(w io.Writer, c *pack.Comparison) error {
+	var b strings.Builder
+
+	b.WriteString("# Context Changes\n\n")
+	b.WriteString(fmt.Sprintf("**From:** %s  \n**To:** %s\n\n", describeSide(c.Old), describeSide(c.New)))
+	b.WriteString("| | Before | After | Change |\n| --- | ---: | ---: | ---: |\n")
+	b.WriteString(fmt.Sprintf("| Files | %d | %d | %s |\n", c.Old.Files, c.New.Files, signed(int64(c.New.Files-c.Old.Files))))
+	b.WriteString(fmt.Sprintf("| Size | %s | %s | %s |\n", utils.FormatBytes(c.Old.Size), utils.FormatBytes(c.New.Size), signedBytes(c.New.Size-c.Old.Size)))
+	b.WriteString(fmt.Sprintf("| Tokens | %d | %d | %s |\n", c.Old.Tokens, c.New.Tokens, signed(int64(c.New.Tokens-c.Old.Tokens))))
+
+	b.WriteString(fmt.Sprintf("\n## Files\n\n%d changed, %d unchanged\n\n", len(c.Files), c.Unchanged))
+	if len(c.Files) > 0 {
+		b.WriteString("| Status | File | Lines | Tokens |\n| --- | --- | ---: | ---: |\n")
+		for _, change := range c.Files {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", change.Status, markdownCell(changeName(change)),
+				fmt.Sprintf("+%d -%d", change.LinesAdded, change.LinesRemoved),
+				signed(int64(change.NewTokens-change.OldTokens))))
+		}
+	}
+
+	if len(c.Languages) > 0 {
+		b.WriteString("\n## Languages\n\n| Language | Files | Size | Tokens |\n| --- | ---: | ---: | ---: |\n")
+		for _, delta := range c.Languages {
+			b.WriteString(fmt.Sprintf("| %s | %d → %d | %s | %s |\n", markdownCell(delta.Language),
+				delta.OldFiles, delta.NewFiles, signedBytes(delta.NewSize-delta.OldSize),
+				signed(int64(delta.NewTokens-delta.OldTokens))))
+		}
+	}
+
+	wroteHeading := false
+	for _, change := range c.Files {
+		if change.Diff == "" {
+			continue
+		}
+		if !wroteHeading {
+			b.WriteString("\n## Diffs\n")
+			wroteHeading = true
+		}
+		// A fence longer than any backtick run in the diff cannot be closed early
+		fence := strings.Repeat("`", max(3, longestRun(change.Diff, '`')+1))
+		b.WriteString(fmt.Sprintf("\n### %s\n\n%sdiff\n%s%s\n", changeName(change), fence, change.Diff, fence))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func describeSide(side pack.PackSummary) string {
+	description := side.Path
+	var details []string
+	if side.Commit != "" {
+		details = append(details, "commit "+side.Commit)
+	}
+	if side.ScanTime != "" {
+		details = append(details, side.ScanTime)
+	}
+	if len(details) > 0 {
+		description += " (" + strings.Join(details, ", ") + ")"
+	}
+	return description
+}
+
+func changeName(change pack.FileChange) string {
+	if change.Status == pack.StatusRenamed {
+		return fmt.Sprintf("%s → %s (%d%%)", change.OldPath, change.Path, change.Similarity)
+	}
+	return change.Path
+}
+
+func changeStat(change pack.FileChange) string {
+	return fmt.Sprintf("+%d -%d  %s", change.LinesAdded, change.LinesRemoved, signedBytes(change.NewSize-change.OldSize))
+}
+
+func signed(n int64) string {
+	if n > 0 {
+		return fmt.Sprintf("+%d", n)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func signedBytes(n int64) string {
+	switch {
+	case n > 0:
+		return "+" + utils.FormatBytes(n)
+	case n < 0:
+		return "-" + utils.FormatBytes(-n)
+	}
+	return "0 B"
+}
+
+// markdownCell keeps a value from breaking a table row
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// longestRun returns the length of the longest run of c in s
+func longestRun(s string, c byte) int {
+	longest, current := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/NesoHQ/code-echo/codeecho-cli/output"
 	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
 	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
 	"github.com/spf13/cobra"
 )
@@ -103,8 +104,9 @@ func scanRepository(path string, showProgress bool, verbose bool) (*ScanResult,
 	return analysisScanner.Scan()
 }
 
-func generateDirectoryTree(files []FileInfo) string {
-	return output.GenerateDirectoryTree(files)
+func generateDirectoryTree(repoPath string, files []FileInfo) string {
+	tree := scanner.TreeFromFiles(repoPath, files)
+	return output.RenderTree(tree, types.OutputOptions{TreeCounts: true})
 }
 
 func formatBytes(bytes int64) string {
@@ -230,7 +232,7 @@ func generateReadmeDoc(result *ScanResult) (string, error) {
 	// Project Structure
 	builder.WriteString("## Project Structure\n\n")
 	builder.WriteString("```\n")
-	builder.WriteString(generateDirectoryTree(result.Files))
+	builder.WriteString(generateDirectoryTree(result.RepoPath, result.Files))
 	builder.WriteString("```\n\n")
 
 	// Key Files
@@ -325,42 +327,11 @@ func analyzeTechStack(files []FileInfo) map[string]int {
 	languages := make(map[string]int)
 
 	for _, file := range files {
-		ext := strings.ToLower(filepath.Ext(file.RelativePath))
-		switch ext {
-		case ".go":
-			languages["Go"]++
-		case ".js":
-			languages["JavaScript"]++
-		case ".ts":
-			languages["TypeScript"]++
-		case ".py":
-			languages["Python"]++
-		case ".java":
-			languages["Java"]++
-		case ".cpp", ".cc":
-			languages["C++"]++
-		case ".c":
-			languages["C"]++
-		case ".rs":
-			languages["Rust"]++
-		case ".rb":
-			languages["Ruby"]++
-		case ".php":
-			languages["PHP"]++
-		case ".html":
-			languages["HTML"]++
-		case ".css":
-			languages["CSS"]++
-		case ".json":
-			languages["JSON"]++
-		case ".md":
-			languages["Markdown"]++
-		case ".yml", ".yaml":
-			languages["YAML"]++
-		default:
-			if ext != "" {
-				languages["Other"]++
-			}
+		switch {
+		case file.Language != "":
+			languages[scanner.LanguageDisplayName(file.Language)]++
+		case filepath.Ext(file.RelativePath) != "":
+			languages["Other"]++
 		}
 	}
 
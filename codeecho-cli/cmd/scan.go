@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/NesoHQ/code-echo/codeecho-cli/config"
@@ -16,22 +17,52 @@ import (
 
 var (
 	// Existing flags remain the same
-	outputFormat         string
+	outputFormats        []string
 	outputFile           string
 	includeSummary       bool
 	includeDirectoryTree bool
 	showLineNumbers      bool
 	outputParsableFormat bool
+	jsonlShape           string
+	xmlContent           string
+	templateFile         string
+	sqliteSymbols        bool
+	chunkTokens          int
+	chunkOverlap         int
+
+	treeStyle    string
+	treeCounts   bool
+	treeSizes    bool
+	treeTokens   bool
+	treeDepth    int
+	treeExcluded bool
+
+	compression string
 
 	compressCode     bool
 	removeComments   bool
 	removeEmptyLines bool
 
+	sampleData      bool
+	sampleRows      int
+	sampleThreshold int
+
+	notebookOutputs     bool
+	notebookOutputLines int
+
+	binaryMetadata bool
+
+	excludeGenerated bool
+	excludeVendored  bool
+
 	excludeDirs    []string
 	includeExts    []string
 	includeContent bool
 	excludeContent bool
 
+	// Set when include_exts comes from the config file
+	includeExtsConfigured bool
+
 	verbose    bool
 	quiet      bool
 	strictMode bool
@@ -51,17 +82,32 @@ codebase in a format optimized for AI tools.
 
 Output Formats:
   xml        - Structured XML format (recommended for AI)
+  claude-xml - Anthropic-style <documents> with CDATA content
   json       - JSON format for programmatic use
+  jsonl      - One JSON record per line for RAG ingestion and fine-tuning
+  chunks     - Overlapping, symbol-aware chunks for vector stores
   markdown   - Human-readable markdown format
+  html       - Self-contained HTML report for reviewing a pack
+  sqlite     - SQLite database with full-text search over content
+  text       - Plain text with "==== path ====" separators
+  template   - Your own layout from a text/template file (--template)
 
 Examples:
   codeecho scan .                              # Basic XML scan
   codeecho scan . --format json               # JSON output
+  codeecho scan . --xml-content cdata         # Unescaped code in CDATA
+  codeecho scan . --template pack.tmpl        # Custom layout
+  codeecho scan . -f sqlite --sqlite-symbols  # Queryable snapshot
+  codeecho scan . -f jsonl --jsonl-shape openai-chat  # Fine-tuning records
+  codeecho scan . -f chunks --chunk-tokens 512 --overlap 64  # Retrieval chunks
 	codeecho scan . --config /path/to/.codeecho.yaml
   codeecho scan . --remove-comments           # Strip comments
   codeecho scan . --compress-code             # Minify code
+  codeecho scan . --sample-data               # Sample large CSV/JSON/log files
+  codeecho scan . --exclude-generated         # Skip lockfiles, *.pb.go, *.min.js
   codeecho scan . --no-summary                # Skip file summary
   codeecho scan . --output packed-repo.xml    # Save to file
+  codeecho scan . -o packed-repo.xml.zst      # Save zstd-compressed
   codeecho scan . --verbose                   # Show detailed progress
   codeecho scan . --strict                    # Fail on any error`,
 	Args: cobra.MaximumNArgs(1),
@@ -71,24 +117,45 @@ Examples:
 func init() {
 	rootCmd.AddCommand(scanCmd)
 
-	scanCmd.Flags().StringVarP(&outputFormat, "format", "f", "xml", "Output format: xml, json, markdown")
-	scanCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: auto-generated)")
+	scanCmd.Flags().StringSliceVarP(&outputFormats, "format", "f", []string{"xml"}, "Output format: xml, claude-xml, json, jsonl, chunks, markdown, html, sqlite, text, template (repeat for several)")
+	scanCmd.Flags().StringVar(&templateFile, "template", "", "text/template file with header, file and footer blocks (selects the template format)")
+	scanCmd.Flags().StringVar(&xmlContent, "xml-content", output.XMLContentEscaped, "XML file content: escaped, cdata")
+	scanCmd.Flags().StringVar(&jsonlShape, "jsonl-shape", output.JSONLShapeFile, "JSONL record shape: file, openai-chat")
+	scanCmd.Flags().BoolVar(&sqliteSymbols, "sqlite-symbols", false, "Fill a symbols table with declarations (sqlite format)")
+	scanCmd.Flags().IntVar(&chunkTokens, "chunk-tokens", scanner.DefaultChunkTokens, "Maximum tokens per chunk (chunks format)")
+	scanCmd.Flags().IntVar(&chunkOverlap, "overlap", scanner.DefaultChunkOverlap, "Tokens repeated from the previous chunk (chunks format)")
+	scanCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file, or directory for several formats (default: auto-generated)")
+	scanCmd.Flags().StringVar(&compression, "compress", "", "Compress output: gzip, zstd, none (default: from the -o suffix, .gz or .zst)")
 	scanCmd.Flags().BoolVar(&includeSummary, "include-summary", true, "Include file summary section")
 	scanCmd.Flags().BoolVar(&includeDirectoryTree, "include-tree", true, "Include directory structure")
+	scanCmd.Flags().StringVar(&treeStyle, "tree-style", output.TreeStyleUnicode, "Directory tree style: unicode, ascii, indent")
+	scanCmd.Flags().BoolVar(&treeCounts, "tree-counts", false, "Show the file count of each directory in the tree")
+	scanCmd.Flags().BoolVar(&treeSizes, "tree-sizes", false, "Show file and directory sizes in the tree")
+	scanCmd.Flags().BoolVar(&treeTokens, "tree-tokens", false, "Show estimated tokens in the tree (reads files once more)")
+	scanCmd.Flags().IntVar(&treeDepth, "tree-depth", 0, "Collapse tree levels below this depth into file counts (0 for all)")
+	scanCmd.Flags().BoolVar(&treeExcluded, "tree-excluded", false, "List excluded and gitignored directories in the tree")
 	scanCmd.Flags().BoolVar(&showLineNumbers, "line-numbers", false, "Show line numbers in code blocks")
 	scanCmd.Flags().BoolVar(&outputParsableFormat, "parsable", true, "Use parsable format tags")
 
 	scanCmd.Flags().BoolVar(&compressCode, "compress-code", false, "Remove unnecessary whitespace from code")
 	scanCmd.Flags().BoolVar(&removeComments, "remove-comments", false, "Strip comments from source files")
 	scanCmd.Flags().BoolVar(&removeEmptyLines, "remove-empty-lines", false, "Remove empty lines from files")
+	scanCmd.Flags().BoolVar(&sampleData, "sample-data", false, "Sample large CSV/TSV, JSONL, log and SQL files and summarize large JSON")
+	scanCmd.Flags().IntVar(&sampleRows, "sample-rows", scanner.DefaultSampleRows, "Rows kept from the start (and end) of sampled data files")
+	scanCmd.Flags().IntVar(&sampleThreshold, "sample-threshold", scanner.DefaultSampleThreshold/1024, "Only sample data files larger than this many KB")
+	scanCmd.Flags().BoolVar(&notebookOutputs, "notebook-outputs", true, "Keep text outputs of Jupyter notebook cells")
+	scanCmd.Flags().IntVar(&notebookOutputLines, "notebook-output-lines", scanner.DefaultNotebookOutputLines, "Max lines kept per notebook cell output")
+	scanCmd.Flags().BoolVar(&binaryMetadata, "binary-metadata", true, "Describe binary files (MIME type, dimensions, archive entries, architecture)")
 	scanCmd.Flags().BoolVar(&includeContent, "content", true, "Include file contents")
 	scanCmd.Flags().BoolVar(&excludeContent, "no-content", false, "Exclude file contents (structure only)")
 	scanCmd.Flags().StringSliceVar(&excludeDirs, "exclude-dirs",
 		[]string{".git", "node_modules", "vendor", ".vscode", ".idea", "target", "build", "dist"},
 		"Directories to exclude")
+	scanCmd.Flags().BoolVar(&excludeGenerated, "exclude-generated", false, "Skip generated files (codegen headers, minified code, lockfiles, linguist-generated)")
+	scanCmd.Flags().BoolVar(&excludeVendored, "exclude-vendored", false, "Skip vendored files (third-party directories, copied libraries, linguist-vendored)")
 	scanCmd.Flags().StringSliceVar(&includeExts, "include-exts",
-		[]string{".go", ".js", ".ts", ".jsx", ".tsx", ".json", ".md", ".html", ".css", ".py", ".java", ".cpp", ".c", ".h", ".rs", ".rb", ".php", ".yml", ".yaml", ".toml", ".xml"},
-		"File extensions to include")
+		[]string{".go", ".js", ".ts", ".jsx", ".tsx", ".json", ".md", ".html", ".css", ".py", ".java", ".cpp", ".c", ".h", ".rs", ".rb", ".php", ".yml", ".yaml", ".toml", ".xml", ".ipynb", ".svg"},
+		"File extensions to include; the default also adds data files with --sample-data")
 
 	// Progress and error handling flags
 	scanCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed progress information")
@@ -115,6 +182,24 @@ func getCliOverrides(cmd *cobra.Command) map[string]bool {
 	if cmd.Flags().Changed("format") {
 		overrides["format"] = true
 	}
+	if cmd.Flags().Changed("sqlite-symbols") {
+		overrides["sqlite-symbols"] = true
+	}
+	if cmd.Flags().Changed("template") {
+		overrides["template"] = true
+	}
+	if cmd.Flags().Changed("xml-content") {
+		overrides["xml-content"] = true
+	}
+	if cmd.Flags().Changed("jsonl-shape") {
+		overrides["jsonl-shape"] = true
+	}
+	if cmd.Flags().Changed("chunk-tokens") {
+		overrides["chunk-tokens"] = true
+	}
+	if cmd.Flags().Changed("overlap") {
+		overrides["overlap"] = true
+	}
 	if cmd.Flags().Changed("exclude-dirs") {
 		overrides["exclude-dirs"] = true
 	}
@@ -133,6 +218,27 @@ func getCliOverrides(cmd *cobra.Command) map[string]bool {
 	if cmd.Flags().Changed("include-tree") {
 		overrides["include-tree"] = true
 	}
+	if cmd.Flags().Changed("compress") {
+		overrides["compress"] = true
+	}
+	if cmd.Flags().Changed("tree-style") {
+		overrides["tree-style"] = true
+	}
+	if cmd.Flags().Changed("tree-counts") {
+		overrides["tree-counts"] = true
+	}
+	if cmd.Flags().Changed("tree-sizes") {
+		overrides["tree-sizes"] = true
+	}
+	if cmd.Flags().Changed("tree-tokens") {
+		overrides["tree-tokens"] = true
+	}
+	if cmd.Flags().Changed("tree-depth") {
+		overrides["tree-depth"] = true
+	}
+	if cmd.Flags().Changed("tree-excluded") {
+		overrides["tree-excluded"] = true
+	}
 	if cmd.Flags().Changed("line-numbers") {
 		overrides["show-line-numbers"] = true
 	}
@@ -145,6 +251,21 @@ func getCliOverrides(cmd *cobra.Command) map[string]bool {
 	if cmd.Flags().Changed("remove-empty-lines") {
 		overrides["remove-empty-lines"] = true
 	}
+	if cmd.Flags().Changed("sample-data") {
+		overrides["sample-data"] = true
+	}
+	if cmd.Flags().Changed("sample-rows") {
+		overrides["sample-rows"] = true
+	}
+	if cmd.Flags().Changed("notebook-outputs") {
+		overrides["notebook-outputs"] = true
+	}
+	if cmd.Flags().Changed("exclude-generated") {
+		overrides["exclude-generated"] = true
+	}
+	if cmd.Flags().Changed("exclude-vendored") {
+		overrides["exclude-vendored"] = true
+	}
 
 	return overrides
 }
@@ -194,6 +315,17 @@ func loadAndMergeConfig(targetPath string, cmd *cobra.Command) error {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	// Custom languages must be registered before any file is detected
+	if err := scanner.RegisterLanguages(cfg.Languages); err != nil {
+		return fmt.Errorf("invalid languages in config: %w", err)
+	}
+
+	// Why: A template is part of the project, so a relative path means
+	// relative to the config file, not to wherever the scan is run from
+	if cfg.Template != "" && !filepath.IsAbs(cfg.Template) {
+		cfg.Template = filepath.Join(filepath.Dir(configPath), cfg.Template)
+	}
+
 	// Step 3: Determine which flags were explicitly set on CLI
 	// This is crucial for proper precedence
 	cliOverrides := getCliOverrides(cmd)
@@ -214,7 +346,26 @@ func loadAndMergeConfig(targetPath string, cmd *cobra.Command) error {
 func mergeConfigIntoFlags(cfg *config.ConfigFile, cliOverrides map[string]bool) {
 	// Format: handled separately in runScan
 	if !cliOverrides["format"] && cfg.Format != "" {
-		outputFormat = cfg.Format
+		outputFormats = []string{cfg.Format}
+	}
+	if !cliOverrides["sqlite-symbols"] && cfg.SQLiteSymbols {
+		sqliteSymbols = cfg.SQLiteSymbols
+	}
+	if !cliOverrides["template"] && cfg.Template != "" {
+		templateFile = cfg.Template
+	}
+	if !cliOverrides["xml-content"] && cfg.XMLContent != "" {
+		xmlContent = cfg.XMLContent
+	}
+	if !cliOverrides["jsonl-shape"] && cfg.JSONLShape != "" {
+		jsonlShape = cfg.JSONLShape
+	}
+	if !cliOverrides["chunk-tokens"] && cfg.ChunkTokens > 0 {
+		chunkTokens = cfg.ChunkTokens
+	}
+	// Why: Pointer so an explicit "chunk_overlap: 0" turns overlap off
+	if !cliOverrides["overlap"] && cfg.ChunkOverlap != nil {
+		chunkOverlap = *cfg.ChunkOverlap
 	}
 
 	// Exclude dirs: merge if not overridden
@@ -225,6 +376,7 @@ func mergeConfigIntoFlags(cfg *config.ConfigFile, cliOverrides map[string]bool)
 	// Include exts: merge if not overridden
 	if !cliOverrides["include-exts"] && len(cfg.IncludeExts) > 0 {
 		includeExts = cfg.IncludeExts
+		includeExtsConfigured = true
 	}
 
 	// Include content: respect config if not explicitly set
@@ -242,6 +394,26 @@ func mergeConfigIntoFlags(cfg *config.ConfigFile, cliOverrides map[string]bool)
 		includeDirectoryTree = cfg.IncludeTree
 	}
 
+	// Tree rendering
+	if !cliOverrides["tree-style"] && cfg.TreeStyle != "" {
+		treeStyle = cfg.TreeStyle
+	}
+	if !cliOverrides["tree-counts"] && cfg.TreeCounts {
+		treeCounts = cfg.TreeCounts
+	}
+	if !cliOverrides["tree-sizes"] && cfg.TreeSizes {
+		treeSizes = cfg.TreeSizes
+	}
+	if !cliOverrides["tree-tokens"] && cfg.TreeTokens {
+		treeTokens = cfg.TreeTokens
+	}
+	if !cliOverrides["tree-depth"] && cfg.TreeDepth > 0 {
+		treeDepth = cfg.TreeDepth
+	}
+	if !cliOverrides["tree-excluded"] && cfg.TreeExcluded {
+		treeExcluded = cfg.TreeExcluded
+	}
+
 	// Show line numbers
 	if !cliOverrides["show-line-numbers"] && cfg.ShowLineNumbers {
 		showLineNumbers = cfg.ShowLineNumbers
@@ -260,10 +432,33 @@ func mergeConfigIntoFlags(cfg *config.ConfigFile, cliOverrides map[string]bool)
 		removeEmptyLines = cfg.RemoveEmptyLines
 	}
 
+	if !cliOverrides["sample-data"] && cfg.SampleData {
+		sampleData = cfg.SampleData
+	}
+
+	if !cliOverrides["sample-rows"] && cfg.SampleRows > 0 {
+		sampleRows = cfg.SampleRows
+	}
+
+	if !cliOverrides["notebook-outputs"] && cfg.NotebookOutputs != nil {
+		notebookOutputs = *cfg.NotebookOutputs
+	}
+
+	if !cliOverrides["exclude-generated"] && cfg.ExcludeGenerated {
+		excludeGenerated = cfg.ExcludeGenerated
+	}
+
+	if !cliOverrides["exclude-vendored"] && cfg.ExcludeVendored {
+		excludeVendored = cfg.ExcludeVendored
+	}
+
 	// Output file
 	if outputFile == "" && cfg.Output != "" {
 		outputFile = cfg.Output
 	}
+	if !cliOverrides["compress"] && cfg.Compress != "" {
+		compression = cfg.Compress
+	}
 
 	// Progress flags
 	if !cliOverrides["verbose"] && cfg.OutputVerbose {
@@ -316,6 +511,65 @@ func runScan(cmd *cobra.Command, args []string) error {
 		gitAware = false
 	}
 
+	// Why: Data sampling would otherwise never see a .csv or .log file; an
+	// explicit list is used as given
+	if sampleData && !cmd.Flags().Changed("include-exts") && !includeExtsConfigured && len(includeExts) > 0 {
+		includeExts = append(includeExts, scanner.DataFileExts...)
+	}
+
+	// A template picks its own layout, so it implies the template format
+	if templateFile != "" {
+		if !cmd.Flags().Changed("format") {
+			outputFormats = []string{"template"}
+		} else if !containsFormat(outputFormats, "template") {
+			return fmt.Errorf("--template cannot be combined with --format %s (add -f template to write both)", strings.Join(outputFormats, ","))
+		}
+	}
+
+	formats, err := uniqueFormats(outputFormats)
+	if err != nil {
+		return err
+	}
+
+	// With several formats -o names the directory their files go to
+	outputDir := ""
+	if outputFile != "" && (len(formats) > 1 || isOutputDir(outputFile)) {
+		outputDir = outputFile
+	}
+
+	if !output.ValidXMLContent(xmlContent) {
+		return fmt.Errorf("invalid --xml-content %q: must be %s or %s", xmlContent, output.XMLContentEscaped, output.XMLContentCDATA)
+	}
+	if !output.ValidJSONLShape(jsonlShape) {
+		return fmt.Errorf("invalid --jsonl-shape %q: must be %s or %s", jsonlShape, output.JSONLShapeFile, output.JSONLShapeOpenAIChat)
+	}
+
+	if !utils.ValidCompression(compression) {
+		return fmt.Errorf("invalid --compress %q: must be %s, %s or %s", compression, utils.CompressGzip, utils.CompressZstd, utils.CompressNone)
+	}
+	// The -o suffix picks the compression unless it was given
+	if suffix := utils.CompressionFromPath(outputFile); suffix != "" && outputDir == "" {
+		if compression == "" {
+			compression = suffix
+		} else if compression != suffix && compression != utils.CompressNone {
+			return fmt.Errorf("--compress %s conflicts with output file %s", compression, outputFile)
+		}
+	}
+
+	if !output.ValidTreeStyle(treeStyle) {
+		return fmt.Errorf("invalid --tree-style %q: must be %s, %s or %s", treeStyle, output.TreeStyleUnicode, output.TreeStyleASCII, output.TreeStyleIndent)
+	}
+	if treeDepth < 0 {
+		return fmt.Errorf("--tree-depth must not be negative, got %d", treeDepth)
+	}
+
+	if chunkTokens <= 0 {
+		return fmt.Errorf("--chunk-tokens must be positive, got %d", chunkTokens)
+	}
+	if chunkOverlap < 0 || chunkOverlap >= chunkTokens {
+		return fmt.Errorf("--overlap must be between 0 and --chunk-tokens (%d), got %d", chunkTokens, chunkOverlap)
+	}
+
 	// Set git timeout if specified
 	if gitTimeout > 0 && gitTimeout != 5 {
 		scanner.SetGitTimeout(time.Duration(gitTimeout) * time.Second)
@@ -347,30 +601,10 @@ func runScan(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Determine output file
-	var outputFilePath string
-	if outputFile != "" {
-		outputFilePath = outputFile
-	} else {
-		outputOpts := types.OutputOptions{
-			IncludeSummary:       includeSummary,
-			IncludeDirectoryTree: includeDirectoryTree,
-			ShowLineNumbers:      showLineNumbers,
-			IncludeContent:       includeContent,
-			RemoveComments:       removeComments,
-			RemoveEmptyLines:     removeEmptyLines,
-			CompressCode:         compressCode,
-		}
-		outputFilePath = utils.GenerateAutoFilename(absPath, outputFormat, outputOpts)
+	if sampleData && !quiet {
+		fmt.Printf("⚙️  Sampling data files larger than %d KB (%d rows)\n", sampleThreshold, sampleRows)
 	}
 
-	// Create output file
-	outFile, err := os.Create(outputFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer outFile.Close()
-
 	// Create output options
 	outputOpts := types.OutputOptions{
 		IncludeSummary:       includeSummary,
@@ -380,14 +614,58 @@ func runScan(cmd *cobra.Command, args []string) error {
 		RemoveComments:       removeComments,
 		RemoveEmptyLines:     removeEmptyLines,
 		CompressCode:         compressCode,
-	}
-
-	// Create streaming writer based on format
-	writer, err := output.NewStreamingWriter(outFile, outputFormat, outputOpts)
+		SampleDataFiles:      sampleData,
+		JSONLShape:           jsonlShape,
+		XMLContent:           xmlContent,
+		Template:             templateFile,
+		SQLiteSymbols:        sqliteSymbols,
+		ChunkTokens:          chunkTokens,
+		ChunkOverlap:         chunkOverlap,
+		TreeStyle:            treeStyle,
+		TreeCounts:           treeCounts,
+		TreeSizes:            treeSizes,
+		TreeTokens:           treeTokens,
+		TreeDepth:            treeDepth,
+		TreeExcluded:         treeExcluded,
+		Compression:          compression,
+	}
+
+	// Determine output files
+	outputPaths, err := resolveOutputPaths(absPath, formats, outputDir, outputOpts)
 	if err != nil {
 		return err
 	}
-	defer writer.Close()
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	// Create a streaming writer per format, all fed by one scan
+	var outputs []*output.MultiOutput
+	// Closed explicitly once the footer is written, to report flush errors
+	closed := false
+	defer func() {
+		if !closed {
+			for _, out := range outputs {
+				out.Writer.Close()
+			}
+		}
+	}()
+	for i, format := range formats {
+		outFile, err := os.Create(outputPaths[i])
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer outFile.Close()
+
+		formatWriter, err := output.NewStreamingWriter(outFile, format, outputOpts)
+		if err != nil {
+			return err
+		}
+		outputs = append(outputs, &output.MultiOutput{Format: format, Path: outputPaths[i], Writer: formatWriter})
+	}
+	writer := output.NewMultiStreamingWriter(outputs, strictMode)
 
 	// Write header
 	scanTime := time.Now().Format(time.RFC3339)
@@ -408,6 +686,15 @@ func runScan(cmd *cobra.Command, args []string) error {
 		IncludeExts:          includeExts,
 		IncludeContent:       includeContent,
 		GitAware:             gitAware,
+		SampleDataFiles:      sampleData,
+		DataSampleRows:       sampleRows,
+		DataSampleThreshold:  int64(sampleThreshold) * 1024,
+		NotebookOutputs:      notebookOutputs,
+		NotebookOutputLines:  notebookOutputLines,
+		BinaryMetadata:       binaryMetadata,
+		ExcludeGenerated:     excludeGenerated,
+		ExcludeVendored:      excludeVendored,
+		TreeTokens:           includeDirectoryTree && treeTokens,
 	}
 
 	streamingScanner := scanner.NewStreamingScanner(absPath, scanOpts, writer.WriteFile)
@@ -471,6 +758,21 @@ func runScan(cmd *cobra.Command, args []string) error {
 	if err := writer.WriteFooter(stats); err != nil {
 		return fmt.Errorf("failed to write footer: %w", err)
 	}
+	closed = true
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to finish output: %w", err)
+	}
+
+	// A writer that failed left a partial file behind, which is removed
+	// rather than passed off as a complete pack
+	for _, out := range writer.Failed() {
+		os.Remove(out.Path)
+		fmt.Fprintf(os.Stderr, "⚠️  %s output failed and was not written: %v\n", out.Format, out.Err)
+	}
+	var writtenPaths []string
+	for _, out := range writer.Succeeded() {
+		writtenPaths = append(writtenPaths, out.Path)
+	}
 
 	duration := time.Since(startTime)
 
@@ -480,11 +782,82 @@ func runScan(cmd *cobra.Command, args []string) error {
 	}
 
 	// Display comprehensive summary
-	displayScanSummary(outputFilePath, stats, scanErrors, duration)
+	displayScanSummary(writtenPaths, stats, scanErrors, duration)
+
+	if skipped := streamingScanner.GetSkippedFiles(); len(skipped) > 0 && !quiet {
+		fmt.Printf("🚫 Skipped %d generated/vendored files\n", len(skipped))
+		if verbose {
+			for _, file := range skipped {
+				fmt.Printf("  • %s: %s\n", file.Path, file.Reason)
+			}
+		}
+		fmt.Println()
+	}
 
 	return nil
 }
 
+// uniqueFormats resolves aliases and drops repeated formats, so
+// "-f md -f markdown" writes one file
+// Why: Unknown formats are rejected here, before any output file exists
+func uniqueFormats(formats []string) ([]string, error) {
+	var unique []string
+	for _, format := range formats {
+		name, ok := output.CanonicalFormat(strings.TrimSpace(format))
+		if !ok {
+			return nil, fmt.Errorf("unsupported format: %s", format)
+		}
+		if !containsFormat(unique, name) {
+			unique = append(unique, name)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("no output format given")
+	}
+	return unique, nil
+}
+
+func containsFormat(formats []string, format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// isOutputDir reports whether -o names a directory: one that exists, or a
+// path ending in a separator
+func isOutputDir(path string) bool {
+	if strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator)) {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// resolveOutputPaths picks the file each format is written to
+// A single format without an output directory keeps -o as given; otherwise
+// every format gets an auto-generated name inside outputDir
+func resolveOutputPaths(absPath string, formats []string, outputDir string, opts types.OutputOptions) ([]string, error) {
+	if len(formats) == 1 && outputDir == "" && outputFile != "" {
+		return []string{outputFile}, nil
+	}
+
+	paths := make([]string, len(formats))
+	seen := make(map[string]string)
+	for i, format := range formats {
+		path := filepath.Join(outputDir, utils.GenerateAutoFilename(absPath, format, opts))
+		// Why: A template producing .md collides with markdown, for example
+		if other, ok := seen[path]; ok {
+			return nil, fmt.Errorf("formats %s and %s would both write %s", other, format, path)
+		}
+		seen[path] = format
+		paths[i] = path
+	}
+	return paths, nil
+}
+
 // Create progress display function
 // Why: Centralized progress handling with verbose/quiet modes
 func createProgressDisplay(verbose bool) scanner.ProgressCallback {
@@ -528,8 +901,15 @@ func createProgressDisplay(verbose bool) scanner.ProgressCallback {
 
 // Display comprehensive scan summary
 // Why: Users need to see what happened - success, warnings, errors
-func displayScanSummary(outputPath string, stats *scanner.StreamingStats, errors []scanner.ScanError, duration time.Duration) {
-	fmt.Printf("\n✅ Output written to %s\n", outputPath)
+func displayScanSummary(outputPaths []string, stats *scanner.StreamingStats, errors []scanner.ScanError, duration time.Duration) {
+	if len(outputPaths) == 1 {
+		fmt.Printf("\n✅ Output written to %s\n", outputPaths[0])
+	} else {
+		fmt.Printf("\n✅ Output written to:\n")
+		for _, path := range outputPaths {
+			fmt.Printf("  • %s\n", path)
+		}
+	}
 
 	fmt.Printf("\n📈 Scan Summary:\n")
 	fmt.Printf("  ├─ Files processed: %d\n", stats.TotalFiles)
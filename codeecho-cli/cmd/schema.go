@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/output"
+	"github.com/spf13/cobra"
+)
+
+var schemaFormat string
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the schema of an output format",
+	Long: `Print the published schema of the json, jsonl or xml output: a JSON Schema
+for json and jsonl (one record per line) and an XSD for xml.
+
+Each of these outputs carries a schema_version. Within a major version,
+new fields are only ever added and are optional, so a consumer written
+against 1.0 can read any 1.x output.
+
+Examples:
+  codeecho schema --format json > codeecho.schema.json
+  codeecho schema --format xml > codeecho.xsd`,
+	Args: cobra.NoArgs,
+	RunE: runSchema,
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+
+	schemaCmd.Flags().StringVarP(&schemaFormat, "format", "f", "json", "Output format whose schema to print: json, jsonl, xml")
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	schema, err := output.Schema(schemaFormat)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(schema)
+	return err
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/pack"
+	"github.com/spf13/cobra"
+)
+
+var (
+	unpackInto      string
+	unpackFormat    string
+	unpackDryRun    bool
+	unpackOverwrite string
+	unpackQuiet     bool
+)
+
+// unpackCmd represents the unpack command
+var unpackCmd = &cobra.Command{
+	Use:   "unpack <pack>",
+	Short: "Restore a repository tree from a pack",
+	Long: `Write the files stored in a CodeEcho pack back to disk.
+
+Reads packs in xml, claude-xml, json, jsonl (file shape) and markdown
+format; the format is detected from the extension and content.
+
+Binary files and files packed without content are skipped. Files whose
+content was changed when the pack was made (comments removed, sampled,
+transcoded) are written but reported, since they cannot be restored
+byte-exact.
+
+Examples:
+  codeecho unpack pack.xml --into ./out
+  codeecho unpack pack.json --into ./out --dry-run
+  codeecho unpack pack.md --into . --overwrite always`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUnpack,
+}
+
+func init() {
+	rootCmd.AddCommand(unpackCmd)
+
+	unpackCmd.Flags().StringVar(&unpackInto, "into", "", "Directory to write the files into (required)")
+	unpackCmd.Flags().StringVar(&unpackFormat, "format", "", "Pack format: xml, claude-xml, json, jsonl, markdown (default: detect)")
+	unpackCmd.Flags().BoolVar(&unpackDryRun, "dry-run", false, "Show what would be written without writing")
+	unpackCmd.Flags().StringVar(&unpackOverwrite, "overwrite", pack.OverwriteSkip, "Existing files: skip, always, fail")
+	unpackCmd.Flags().BoolVarP(&unpackQuiet, "quiet", "q", false, "Only print skipped and inexact files")
+
+	unpackCmd.MarkFlagRequired("into")
+}
+
+func runUnpack(cmd *cobra.Command, args []string) error {
+	// Arguments are valid by now; errors below are not usage mistakes
+	cmd.SilenceUsage = true
+
+	if !pack.ValidOverwrite(unpackOverwrite) {
+		return fmt.Errorf("invalid --overwrite: %q (use skip, always or fail)", unpackOverwrite)
+	}
+
+	var p *pack.Pack
+	var err error
+	if unpackFormat != "" {
+		p, err = readPackAs(args[0], unpackFormat)
+	} else {
+		p, err = pack.Read(args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := pack.Unpack(p, pack.UnpackOptions{
+		Dir:       unpackInto,
+		DryRun:    unpackDryRun,
+		Overwrite: unpackOverwrite,
+	})
+	if err != nil {
+		return err
+	}
+
+	verb := "Wrote"
+	if unpackDryRun {
+		verb = "Would write"
+	}
+	if !unpackQuiet {
+		for _, path := range report.Written {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+	if len(report.Skipped) > 0 {
+		fmt.Printf("\n⚠️  Skipped %d file(s):\n", len(report.Skipped))
+		for _, entry := range report.Skipped {
+			fmt.Printf("  %s (%s)\n", entry.Path, entry.Reason)
+		}
+	}
+	if len(report.Inexact) > 0 {
+		fmt.Printf("\n⚠️  %d file(s) differ from the original:\n", len(report.Inexact))
+		for _, entry := range report.Inexact {
+			fmt.Printf("  %s (%s)\n", entry.Path, entry.Reason)
+		}
+	}
+
+	if report.Unverified > 0 {
+		fmt.Printf("\nℹ️  %d file(s) could not be verified: the pack records no hashes\n", report.Unverified)
+	}
+
+	fmt.Printf("\n✅ %s %d of %d file(s) from %s pack into %s\n",
+		verb, len(report.Written), len(p.Files), p.Format, unpackInto)
+	return nil
+}
+
+// readPackAs parses a pack in a format given on the command line
+func readPackAs(path, format string) (*pack.Pack, error) {
+	data, err := pack.ReadData(path)
+	if err != nil {
+		return nil, err
+	}
+	return pack.Parse(data, format)
+}
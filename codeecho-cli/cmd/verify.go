@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/pack"
+	"github.com/spf13/cobra"
+)
+
+var verifyFormat string
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify <pack> [repo]",
+	Short: "Check a pack's integrity and whether it is stale",
+	Long: `Check that a pack has not been edited since it was made, and optionally
+that it still matches a repository.
+
+Every file's content is checked against its recorded content hash, and the
+manifest digest in the footer is recomputed from the file list. Given a
+repository, each file's original hash is compared with the file on disk
+to list packed files that have since changed or been deleted.
+
+The command exits with an error when any check fails.
+
+Examples:
+  codeecho verify pack.xml         # Integrity only
+  codeecho verify pack.xml .       # Integrity and staleness`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringVar(&verifyFormat, "format", "", "Pack format: xml, claude-xml, json, jsonl, markdown (default: detect)")
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	var p *pack.Pack
+	var err error
+	if verifyFormat != "" {
+		p, err = readPackAs(args[0], verifyFormat)
+	} else {
+		p, err = pack.Read(args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	repoDir := ""
+	if len(args) > 1 {
+		repoDir = args[1]
+	}
+	report, err := pack.Verify(p, repoDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pack: %s (%s, %d files)\n\n", args[0], p.Format, report.Files)
+
+	switch report.Manifest {
+	case pack.ManifestOK:
+		fmt.Printf("✅ Manifest matches (%s)\n", p.ManifestDigest)
+	case pack.ManifestMissing:
+		fmt.Println("⚠️  No manifest (pack made before manifests were added)")
+	case pack.ManifestMismatch:
+		fmt.Printf("❌ Manifest mismatch: %s\n", report.ManifestDetail)
+	}
+
+	if len(report.Tampered) == 0 {
+		fmt.Printf("✅ Content hashes match (%d files checked)\n", report.ContentChecked)
+	} else {
+		fmt.Printf("❌ %d of %d files were edited after packing:\n", len(report.Tampered), report.ContentChecked)
+		for _, entry := range report.Tampered {
+			fmt.Printf("  %s (%s)\n", entry.Path, entry.Reason)
+		}
+	}
+
+	if report.RepoChecked {
+		if len(report.Stale) == 0 {
+			fmt.Printf("✅ Up to date with %s (%d files)\n", repoDir, report.Current)
+		} else {
+			fmt.Printf("❌ %d file(s) changed in %s since the pack was made:\n", len(report.Stale), repoDir)
+			for _, entry := range report.Stale {
+				fmt.Printf("  %s (%s)\n", entry.Path, entry.Reason)
+			}
+		}
+		if report.Unhashed > 0 {
+			fmt.Printf("⚠️  %d file(s) have no recorded hash and were not compared\n", report.Unhashed)
+		}
+	}
+
+	if !report.OK() {
+		return fmt.Errorf("verification failed")
+	}
+	return nil
+}
@@ -5,13 +5,21 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/NesoHQ/code-echo/codeecho-cli/output"
 	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
 	"gopkg.in/yaml.v3"
 )
 
 type ConfigFile struct {
 	// Scanning options
 	Format          string   `yaml:"format" json:"format"`
+	JSONLShape      string   `yaml:"jsonl_shape" json:"jsonl_shape"`
+	XMLContent      string   `yaml:"xml_content" json:"xml_content"`
+	Template        string   `yaml:"template" json:"template"`
+	SQLiteSymbols   bool     `yaml:"sqlite_symbols" json:"sqlite_symbols"`
+	ChunkTokens     int      `yaml:"chunk_tokens" json:"chunk_tokens"`
+	ChunkOverlap    *int     `yaml:"chunk_overlap" json:"chunk_overlap"`
 	ExcludeDirs     []string `yaml:"exclude_dirs" json:"exclude_dirs"`
 	IncludeExts     []string `yaml:"include_exts" json:"include_exts"`
 	IncludeContent  bool     `yaml:"include_content" json:"include_content"`
@@ -19,13 +27,36 @@ type ConfigFile struct {
 	IncludeTree     bool     `yaml:"include_tree" json:"include_tree"`
 	ShowLineNumbers bool     `yaml:"show_line_numbers" json:"show_line_numbers"`
 
+	// Directory tree rendering
+	TreeStyle    string `yaml:"tree_style" json:"tree_style"`
+	TreeCounts   bool   `yaml:"tree_counts" json:"tree_counts"`
+	TreeSizes    bool   `yaml:"tree_sizes" json:"tree_sizes"`
+	TreeTokens   bool   `yaml:"tree_tokens" json:"tree_tokens"`
+	TreeDepth    int    `yaml:"tree_depth" json:"tree_depth"`
+	TreeExcluded bool   `yaml:"tree_excluded" json:"tree_excluded"`
+
 	// Processing options
 	CompressCode     bool `yaml:"compress_code" json:"compress_code"`
 	RemoveComments   bool `yaml:"remove_comments" json:"remove_comments"`
 	RemoveEmptyLines bool `yaml:"remove_empty_lines" json:"remove_empty_lines"`
 
+	// Data file sampling
+	SampleData bool `yaml:"sample_data" json:"sample_data"`
+	SampleRows int  `yaml:"sample_rows" json:"sample_rows"`
+
+	// Generated/vendored classification
+	ExcludeGenerated bool `yaml:"exclude_generated" json:"exclude_generated"`
+	ExcludeVendored  bool `yaml:"exclude_vendored" json:"exclude_vendored"`
+
+	// Jupyter notebooks (nil means "use the CLI default")
+	NotebookOutputs *bool `yaml:"notebook_outputs" json:"notebook_outputs"`
+
+	// Extra or overridden language registry entries
+	Languages []scanner.Language `yaml:"languages" json:"languages"`
+
 	// Output options
 	Output        string `yaml:"output" json:"output"`
+	Compress      string `yaml:"compress" json:"compress"`
 	OutputQuiet   bool   `yaml:"quiet" json:"quiet"`
 	OutputVerbose bool   `yaml:"verbose" json:"verbose"`
 
@@ -184,9 +215,28 @@ func CreateDefaultConfigFile() string {
 	return `# CodeEcho Configuration File
 # Save as .codeecho.yaml in your project root
 
-# Output format: xml, json, or markdown
+# Output format: xml, claude-xml, json, jsonl, chunks, markdown, html, sqlite,
+# text, or template
 format: xml
 
+# Custom layout for the template format (text/template with header, file
+# and footer blocks); setting it selects the template format
+# template: .codeecho.tmpl
+
+# SQLite format: also fill a symbols table with functions, types and classes
+sqlite_symbols: false
+
+# XML file content: escaped (entities) or cdata (smaller, reads as written)
+xml_content: escaped
+
+# JSONL record shape: file (metadata, one record per file, stats) or
+# openai-chat (chat fine-tuning messages)
+jsonl_shape: file
+
+# Chunks format: maximum tokens per chunk and tokens repeated between chunks
+chunk_tokens: 512
+chunk_overlap: 64
+
 # Git awareness - respects .gitignore and captures repo metadata
 gitAware: true
 
@@ -200,6 +250,8 @@ exclude_dirs:
   - .vscode
   - .idea
 
+# Replaces the built-in list, which also takes in data files with
+# sample_data
 include_exts:
   - .go
   - .js
@@ -211,6 +263,8 @@ include_exts:
   - .html
   - .css
   - .py
+  - .ipynb
+  - .svg
 
 # Content options
 include_content: true
@@ -218,13 +272,45 @@ include_summary: true
 include_tree: true
 show_line_numbers: false
 
+# Directory tree: style (unicode, ascii or indent), per-directory file
+# counts, sizes, estimated tokens, depth limit (0 for all) and excluded
+# directories
+tree_style: unicode
+tree_counts: false
+tree_sizes: false
+tree_tokens: false
+tree_depth: 0
+tree_excluded: false
+
 # Processing options
 compress_code: false
 remove_comments: false
 remove_empty_lines: false
 
+# Sample large CSV/TSV, JSONL, log and SQL files (keeps header + first/last rows)
+sample_data: false
+sample_rows: 10
+
+# Skip generated (codegen, minified, lockfiles) and vendored files
+# .gitattributes linguist-generated / linguist-vendored are respected
+exclude_generated: false
+exclude_vendored: false
+
+# Keep text outputs when flattening Jupyter notebooks
+notebook_outputs: true
+
+# Extend the built-in language registry (same fields as languages.yml)
+# languages:
+#   - id: mylang
+#     name: MyLang
+#     type: programming
+#     extensions: [".myl"]
+#     line_comments: ["//"]
+#     block_comments: [["/*", "*/"]]
+
 # Output options
 output: ""      # Leave empty for auto-generated filenames
+compress: ""    # gzip or zstd; empty picks it from the output suffix (.gz, .zst)
 quiet: false
 verbose: false
 
@@ -237,9 +323,9 @@ verbose: false
 func (c *ConfigFile) Validate() error {
 	// Validate format
 	if c.Format != "" {
-		validFormats := map[string]bool{"xml": true, "json": true, "markdown": true, "md": true}
+		validFormats := map[string]bool{"xml": true, "claude-xml": true, "json": true, "jsonl": true, "chunks": true, "markdown": true, "md": true, "html": true, "sqlite": true, "text": true, "txt": true, "template": true}
 		if !validFormats[c.Format] {
-			return fmt.Errorf("invalid format '%s': must be xml, json, or markdown", c.Format)
+			return fmt.Errorf("invalid format '%s': must be xml, claude-xml, json, jsonl, chunks, markdown, html, sqlite, text, or template", c.Format)
 		}
 	}
 
@@ -255,6 +341,36 @@ func (c *ConfigFile) Validate() error {
 		}
 	}
 
+	if !output.ValidJSONLShape(c.JSONLShape) {
+		return fmt.Errorf("invalid jsonl_shape '%s': must be file or openai-chat", c.JSONLShape)
+	}
+
+	if c.Format == "template" && c.Template == "" {
+		return fmt.Errorf("format 'template' requires a template file")
+	}
+
+	if !output.ValidXMLContent(c.XMLContent) {
+		return fmt.Errorf("invalid xml_content '%s': must be escaped or cdata", c.XMLContent)
+	}
+
+	if !utils.ValidCompression(c.Compress) {
+		return fmt.Errorf("invalid compress '%s': must be gzip, zstd or none", c.Compress)
+	}
+
+	if !output.ValidTreeStyle(c.TreeStyle) {
+		return fmt.Errorf("invalid tree_style '%s': must be unicode, ascii or indent", c.TreeStyle)
+	}
+	if c.TreeDepth < 0 {
+		return fmt.Errorf("invalid tree_depth %d: must not be negative", c.TreeDepth)
+	}
+
+	if c.ChunkTokens < 0 {
+		return fmt.Errorf("invalid chunk_tokens %d: must be positive", c.ChunkTokens)
+	}
+	if c.ChunkOverlap != nil && *c.ChunkOverlap < 0 {
+		return fmt.Errorf("invalid chunk_overlap %d: must not be negative", *c.ChunkOverlap)
+	}
+
 	// Check for conflicting flags
 	if c.OutputQuiet && c.OutputVerbose {
 		return fmt.Errorf("cannot use both quiet and verbose modes")
@@ -0,0 +1,100 @@
+// Highlights a code block the first time it is opened, so large reports
+// stay fast. Comment markers come from CodeEcho's language registry.
+(function () {
+  var keywords = new Set(("abstract and as async await break case catch chan class const continue crate def default defer del do " +
+    "elif else elsif end enum except export extends extern false final finally fn for foreach from func function go goto " +
+    "if impl implements import in instanceof interface is lambda let loop match mod module mut new nil none not null " +
+    "or package pass private protected pub public raise range return select self static struct super switch then this " +
+    "throw throws trait true try type typeof unless until use using val var void when where while with yield").split(" "));
+
+  function escape(s) {
+    return s.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;");
+  }
+  function span(cls, s) {
+    return '<span class="' + cls + '">' + escape(s) + "</span>";
+  }
+
+  function highlight(code) {
+    var text = code.textContent;
+    var line = (code.dataset.lc || "").split(" ").filter(Boolean);
+    var blocks = (code.dataset.bc || "").split(" ").filter(Boolean);
+    var out = "", i = 0, plain = "";
+
+    function flush() { out += escape(plain); plain = ""; }
+    function startsAt(marker) { return text.substr(i, marker.length) === marker; }
+
+    outer:
+    while (i < text.length) {
+      var c = text[i];
+      for (var b = 0; b + 1 < blocks.length; b += 2) {
+        if (startsAt(blocks[b])) {
+          var end = text.indexOf(blocks[b + 1], i + blocks[b].length);
+          end = end < 0 ? text.length : end + blocks[b + 1].length;
+          flush(); out += span("com", text.slice(i, end)); i = end;
+          continue outer;
+        }
+      }
+      for (var l = 0; l < line.length; l++) {
+        // Line markers only count at the start of a token, like the scanner's stripper
+        if (startsAt(line[l]) && (i === 0 || /\s/.test(text[i - 1]))) {
+          var eol = text.indexOf("\n", i);
+          eol = eol < 0 ? text.length : eol;
+          flush(); out += span("com", text.slice(i, eol)); i = eol;
+          continue outer;
+        }
+      }
+      if (c === '"' || c === "'" || c === "`") {
+        var j = i + 1;
+        while (j < text.length && text[j] !== c && (c === "`" || text[j] !== "\n")) {
+          j += text[j] === "\\" ? 2 : 1;
+        }
+        j = Math.min(j + 1, text.length);
+        flush(); out += span("str", text.slice(i, j)); i = j;
+        continue;
+      }
+      var word = /^[A-Za-z_$][\w$]*/.exec(text.slice(i, i + 64));
+      if (word) {
+        flush();
+        out += keywords.has(word[0]) ? span("kw", word[0]) : escape(word[0]);
+        i += word[0].length;
+        continue;
+      }
+      var num = /^(?:0x[\da-fA-F_]+|\d[\d_]*(?:\.\d+)?(?:[eE][+-]?\d+)?)/.exec(text.slice(i, i + 64));
+      if (num && (i === 0 || !/[\w$]/.test(text[i - 1]))) {
+        flush(); out += span("num", num[0]); i += num[0].length;
+        continue;
+      }
+      plain += c; i++;
+    }
+    flush();
+    code.innerHTML = out;
+  }
+
+  document.querySelectorAll("details.file").forEach(function (details) {
+    details.addEventListener("toggle", function () {
+      var code = details.querySelector("code[data-lang]");
+      if (details.open && code && !code.dataset.done) {
+        code.dataset.done = "1";
+        highlight(code);
+      }
+    });
+  });
+
+  // Opening a file from the tree or heatmap expands it
+  function openTarget() {
+    var target = location.hash && document.getElementById(location.hash.slice(1));
+    if (target && target.tagName === "DETAILS") { target.open = true; }
+  }
+  window.addEventListener("hashchange", openTarget);
+  openTarget();
+
+  var toggle = document.getElementById("heat-toggle");
+  if (toggle) {
+    toggle.addEventListener("click", function () {
+      var map = document.getElementById("heatmap");
+      var bySize = map.classList.toggle("by-size");
+      toggle.textContent = bySize ? "Show tokens" : "Show size";
+      document.getElementById("heat-metric").textContent = bySize ? "size" : "tokens";
+    });
+  }
+})();
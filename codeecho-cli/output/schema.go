@@ -0,0 +1,50 @@
+package output
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is the MAJOR.MINOR version of the json, jsonl and xml layouts
+// Why: Pipelines parse these outputs; a version lets them tell an additive
+// change (new minor, still readable) from a breaking one (new major)
+// Bump the minor when adding an optional field, the major when removing,
+// renaming or retyping one, and update the schemas in schema/ either way
+const SchemaVersion = "1.2"
+
+var (
+	//go:embed schema/codeecho.schema.json
+	jsonSchema []byte
+	//go:embed schema/codeecho-jsonl.schema.json
+	jsonlSchema []byte
+	//go:embed schema/codeecho.xsd
+	xmlSchema []byte
+)
+
+// SchemaFormats lists the formats with a published schema
+var SchemaFormats = []string{"json", "jsonl", "xml"}
+
+// Schema returns the JSON Schema or XSD describing format
+func Schema(format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return jsonSchema, nil
+	case "jsonl":
+		return jsonlSchema, nil
+	case "xml":
+		return xmlSchema, nil
+	}
+	return nil, fmt.Errorf("no schema for format %q (use %s)", format, strings.Join(SchemaFormats, ", "))
+}
+
+// SchemaMajor returns the major part of a MAJOR.MINOR schema version
+func SchemaMajor(version string) (int, error) {
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q", version)
+	}
+	return n, nil
+}
@@ -0,0 +1,674 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+)
+
+// TestSchemas writes a sample scan in every format with a published schema
+// and validates it against that schema
+// Why: The schemas are written by hand next to the writers; this catches a
+// field added to a writer without the schema (and a version bump) before a
+// release ships it
+func TestSchemas(t *testing.T) {
+	for _, format := range SchemaFormats {
+		t.Run(format, func(t *testing.T) {
+			if err := checkSchema(format); err != nil {
+				t.Errorf("%s output does not match schema %s: %v", format, SchemaVersion, err)
+			}
+		})
+	}
+}
+
+// checkSchema validates sample output of format against its schema
+// The sample sets every optional field so each one is checked
+func checkSchema(format string) error {
+	schema, err := Schema(format)
+	if err != nil {
+		return err
+	}
+
+	variants := []types.OutputOptions{schemaCheckOptions(false)}
+	if format == "xml" {
+		// Content is written differently, so check both modes
+		cdataOpts := schemaCheckOptions(true)
+		cdataOpts.XMLContent = XMLContentCDATA
+		variants = append(variants, cdataOpts)
+	}
+
+	for _, opts := range variants {
+		var buf bytes.Buffer
+		if err := writeSchemaSample(&buf, format, opts); err != nil {
+			return fmt.Errorf("failed to write sample: %w", err)
+		}
+
+		switch format {
+		case "json":
+			err = validateJSON(schema, buf.Bytes())
+		case "jsonl":
+			err = validateJSONLines(schema, buf.Bytes())
+		case "xml":
+			err = validateXML(schema, buf.Bytes())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func schemaCheckOptions(lineNumbers bool) types.OutputOptions {
+	return types.OutputOptions{
+		IncludeSummary:       true,
+		IncludeDirectoryTree: true,
+		IncludeContent:       true,
+		ShowLineNumbers:      lineNumbers,
+		RemoveComments:       true,
+		SampleDataFiles:      true,
+		JSONLShape:           JSONLShapeFile,
+	}
+}
+
+// writeSchemaSample writes a small scan that sets every optional field
+func writeSchemaSample(w io.Writer, format string, opts types.OutputOptions) error {
+	files := []scanner.FileInfo{
+		{
+			Path:               "/repo/cmd/main.go",
+			RelativePath:       "cmd/main.go",
+			Size:               1536,
+			SizeFormatted:      "1.5 KB",
+			ModTime:            "2024-01-02T03:04:05Z",
+			ModTimeFormatted:   "2024-01-02 03:04:05",
+			Content:            "package main\n\n// <main> & \"more\" ]]>\nfunc main() {}\n",
+			Language:           "go",
+			LineCount:          4,
+			TokenCount:         14,
+			Extension:          ".go",
+			IsText:             true,
+			LanguageConfidence: 0.95,
+			DetectionMethod:    "extension",
+			Category:           scanner.CategorySource,
+			Encoding:           "utf-8",
+			LineEnding:         scanner.LineEndingLF,
+			IndentStyle:        scanner.IndentStyleTab,
+			IndentSize:         4,
+			SHA256:             strings.Repeat("a", 64),
+			ContentSHA256:      strings.Repeat("b", 64),
+			MimeType:           "text/x-go",
+		},
+		{
+			Path:              "/repo/data/rows.csv",
+			RelativePath:      "data/rows.csv",
+			Size:              2 << 20,
+			SizeFormatted:     "2.0 MB",
+			ModTime:           "2024-01-02T03:04:05Z",
+			ModTimeFormatted:  "2024-01-02 03:04:05",
+			Content:           "id,name\n1,a\n",
+			Language:          "csv",
+			LineCount:         2,
+			TokenCount:        6,
+			Extension:         ".csv",
+			IsText:            true,
+			Sampled:           true,
+			OriginalLineCount: 50000,
+			Flattened:         true,
+			Category:          scanner.CategoryGenerated,
+			CategoryReason:    "path matches generated pattern",
+			Encoding:          "utf-16le",
+			LineEnding:        scanner.LineEndingCRLF,
+			SHA256:            strings.Repeat("c", 64),
+			ContentSHA256:     strings.Repeat("d", 64),
+		},
+		{
+			Path:             "/repo/assets/logo.svg",
+			RelativePath:     "assets/logo.svg",
+			Size:             80,
+			SizeFormatted:    "80 B",
+			ModTime:          "2024-01-02T03:04:05Z",
+			ModTimeFormatted: "2024-01-02 03:04:05",
+			Content:          "<svg width=\"40\" height=\"30\"/>\n",
+			Language:         "svg",
+			LineCount:        1,
+			TokenCount:       8,
+			Extension:        ".svg",
+			IsText:           true,
+			MimeType:         "image/svg+xml",
+			Binary:           &scanner.BinaryMetadata{Format: "svg", Width: 40, Height: 30},
+		},
+		{
+			Path:             "/repo/assets/bundle.zip",
+			RelativePath:     "assets/bundle.zip",
+			Size:             4096,
+			SizeFormatted:    "4.0 KB",
+			ModTime:          "2024-01-02T03:04:05Z",
+			ModTimeFormatted: "2024-01-02 03:04:05",
+			Extension:        ".zip",
+			Category:         scanner.CategoryVendored,
+			SHA256:           strings.Repeat("e", 64),
+			MimeType:         "application/zip",
+			Binary: &scanner.BinaryMetadata{
+				Format:       "zip",
+				Width:        1,
+				Height:       1,
+				PageCount:    1,
+				EntryCount:   2,
+				Entries:      []string{"a.txt", "b.txt"},
+				Architecture: "x86-64",
+				Imports:      []string{"libc.so.6"},
+			},
+		},
+	}
+
+	writer, err := NewStreamingWriter(w, format, opts)
+	if err != nil {
+		return err
+	}
+
+	stats := scanner.NewStreamingStats()
+	manifest := scanner.NewManifest()
+	tree := scanner.TreeFromFiles("/repo", files)
+
+	if err := writer.WriteHeader("/repo", "2024-01-02T03:04:05Z"); err != nil {
+		return err
+	}
+	if err := writer.WriteGitMetadata(&scanner.GitMetadata{
+		Branch:      "main",
+		CommitHash:  strings.Repeat("f", 40),
+		Author:      "A Developer",
+		CommitDate:  "2024-01-01T00:00:00Z",
+		CommitCount: 42,
+	}); err != nil {
+		return err
+	}
+	if err := writer.WriteTree(tree); err != nil {
+		return err
+	}
+	for i := range files {
+		file := &files[i]
+		if err := writer.WriteFile(file); err != nil {
+			return err
+		}
+		stats.AddFile(file)
+		manifest.Add(file.RelativePath, file.SHA256, file.ContentSHA256)
+	}
+	stats.ManifestDigest = manifest.Digest()
+	stats.Skipped = []scanner.SkippedFile{{Path: "package-lock.json", Reason: "generated (lockfile)"}}
+	stats.Errors = []scanner.ScanError{
+		{Path: "/repo/broken.go", Phase: "read", Error: errors.New("permission denied"), Skipped: true},
+		{Path: "/repo/.editorconfig", Phase: "editorconfig", Error: errors.New("bad <section> & value")},
+	}
+	stats.Duration = 1500 * time.Millisecond
+	if err := writer.WriteFooter(stats); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// validateJSON checks a JSON document against a JSON Schema
+// Only the keywords the published schemas use are supported
+func validateJSON(schema, document []byte) error {
+	v, err := newJSONValidator(schema)
+	if err != nil {
+		return err
+	}
+	value, err := decodeJSON(document)
+	if err != nil {
+		return fmt.Errorf("output is not valid JSON: %w", err)
+	}
+	return v.validate(v.root, value, "$")
+}
+
+// validateJSONLines checks each line of a JSONL document
+func validateJSONLines(schema, document []byte) error {
+	v, err := newJSONValidator(schema)
+	if err != nil {
+		return err
+	}
+
+	lines := bufio.NewScanner(bytes.NewReader(document))
+	lines.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for n := 1; lines.Scan(); n++ {
+		value, err := decodeJSON(lines.Bytes())
+		if err != nil {
+			return fmt.Errorf("line %d is not valid JSON: %w", n, err)
+		}
+		if err := v.validate(v.root, value, fmt.Sprintf("line %d", n)); err != nil {
+			return err
+		}
+	}
+	return lines.Err()
+}
+
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Numbers stay json.Number so integers can be told from decimals
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+type jsonValidator struct {
+	root map[string]any
+}
+
+func newJSONValidator(schema []byte) (*jsonValidator, error) {
+	var root map[string]any
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+	return &jsonValidator{root: root}, nil
+}
+
+func (v *jsonValidator) validate(schema map[string]any, value any, at string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name, found := strings.CutPrefix(ref, "#/$defs/")
+		defs, _ := v.root["$defs"].(map[string]any)
+		target, _ := defs[name].(map[string]any)
+		if !found || target == nil {
+			return fmt.Errorf("schema: unresolved $ref %q", ref)
+		}
+		if err := v.validate(target, value, at); err != nil {
+			return err
+		}
+	}
+
+	if expected, ok := schema["const"]; ok && !jsonEqual(expected, value) {
+		return fmt.Errorf("%s: expected %v, got %v", at, expected, value)
+	}
+
+	if options, ok := schema["enum"].([]any); ok {
+		matched := false
+		for _, option := range options {
+			if jsonEqual(option, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: %v is not one of %v", at, value, options)
+		}
+	}
+
+	if typ, ok := schema["type"].(string); ok && !jsonHasType(value, typ) {
+		return fmt.Errorf("%s: expected %s, got %T", at, typ, value)
+	}
+
+	if branches, ok := schema["oneOf"].([]any); ok {
+		matches := 0
+		var errs []error
+		for _, branch := range branches {
+			branchSchema, _ := branch.(map[string]any)
+			if err := v.validate(branchSchema, value, at); err != nil {
+				errs = append(errs, err)
+			} else {
+				matches++
+			}
+		}
+		if matches != 1 {
+			reasons := make([]string, len(errs))
+			for i, err := range errs {
+				reasons[i] = strings.TrimPrefix(err.Error(), at+": ")
+			}
+			return fmt.Errorf("%s: matches %d of the oneOf schemas, want 1 (%s)", at, matches, strings.Join(reasons, "; "))
+		}
+	}
+
+	switch value := value.(type) {
+	case string:
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("schema: invalid pattern %q: %w", pattern, err)
+			}
+			if !re.MatchString(value) {
+				return fmt.Errorf("%s: %q does not match %s", at, value, pattern)
+			}
+		}
+
+	case json.Number:
+		n, _ := value.Float64()
+		if minimum, ok := schema["minimum"].(float64); ok && n < minimum {
+			return fmt.Errorf("%s: %v is below the minimum %v", at, value, minimum)
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && n > maximum {
+			return fmt.Errorf("%s: %v is above the maximum %v", at, value, maximum)
+		}
+
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				if err := v.validate(items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				if _, present := value[name.(string)]; !present {
+					return fmt.Errorf("%s: missing required %q", at, name)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, field := range value {
+			fieldAt := at + "." + name
+			if fieldSchema, ok := properties[name].(map[string]any); ok {
+				if err := v.validate(fieldSchema, field, fieldAt); err != nil {
+					return err
+				}
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s: not in the schema", fieldAt)
+				}
+			case map[string]any:
+				if err := v.validate(additional, field, fieldAt); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func jsonHasType(value any, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+// jsonEqual compares a schema value with a document value
+// Schema numbers decode as float64, document numbers as json.Number
+func jsonEqual(expected, value any) bool {
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		return err == nil && reflect.DeepEqual(expected, f)
+	}
+	return reflect.DeepEqual(expected, value)
+}
+
+// xsdNode is any element of an XSD document
+type xsdNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xsdNode  `xml:",any"`
+}
+
+func (n *xsdNode) attr(name string) string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// xsdElement is an element declaration flattened for checking
+type xsdElement struct {
+	name       string
+	textType   string // Simple content type; empty for complex content
+	mixed      bool
+	children   map[string]*xsdElement
+	attributes map[string]xsdAttribute
+}
+
+type xsdAttribute struct {
+	typ      string
+	pattern  string // From an inline restriction
+	required bool
+}
+
+type xsdSchema struct {
+	root     *xsdElement
+	patterns map[string]string // Named simple types restricted by a pattern
+}
+
+// validateXML checks an XML document against an XSD
+// Element names, attributes, required attributes, text and value types are
+// checked; element order and occurrence counts are not
+func validateXML(schema, document []byte) error {
+	xsd, err := parseXSD(schema)
+	if err != nil {
+		return err
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	var stack []*xsdElement
+	var text strings.Builder
+	var path []string
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("output is not well-formed XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			var decl *xsdElement
+			if len(stack) == 0 {
+				if t.Name.Local == xsd.root.name {
+					decl = xsd.root
+				}
+			} else {
+				decl = stack[len(stack)-1].children[t.Name.Local]
+			}
+			path = append(path, t.Name.Local)
+			at := "/" + strings.Join(path, "/")
+			if decl == nil {
+				return fmt.Errorf("%s: element not in the schema", at)
+			}
+
+			seen := make(map[string]bool)
+			for _, attr := range t.Attr {
+				attrDecl, ok := decl.attributes[attr.Name.Local]
+				if !ok {
+					return fmt.Errorf("%s@%s: attribute not in the schema", at, attr.Name.Local)
+				}
+				if err := xsd.checkValue(attrDecl.typ, attrDecl.pattern, attr.Value); err != nil {
+					return fmt.Errorf("%s@%s: %w", at, attr.Name.Local, err)
+				}
+				seen[attr.Name.Local] = true
+			}
+			for name, attrDecl := range decl.attributes {
+				if attrDecl.required && !seen[name] {
+					return fmt.Errorf("%s: missing required attribute %q", at, name)
+				}
+			}
+
+			stack = append(stack, decl)
+			text.Reset()
+
+		case xml.CharData:
+			if len(stack) == 0 {
+				continue
+			}
+			decl := stack[len(stack)-1]
+			if decl.textType == "" && !decl.mixed && strings.TrimSpace(string(t)) != "" {
+				return fmt.Errorf("/%s: text not allowed", strings.Join(path, "/"))
+			}
+			text.Write(t)
+
+		case xml.EndElement:
+			decl := stack[len(stack)-1]
+			if decl.textType != "" {
+				if err := xsd.checkValue(decl.textType, "", strings.TrimSpace(text.String())); err != nil {
+					return fmt.Errorf("/%s: %w", strings.Join(path, "/"), err)
+				}
+			}
+			stack = stack[:len(stack)-1]
+			path = path[:len(path)-1]
+			text.Reset()
+		}
+	}
+	return nil
+}
+
+func parseXSD(schema []byte) (*xsdSchema, error) {
+	var doc xsdNode
+	if err := xml.Unmarshal(schema, &doc); err != nil {
+		return nil, fmt.Errorf("invalid XSD: %w", err)
+	}
+
+	xsd := &xsdSchema{patterns: make(map[string]string)}
+	for i := range doc.Children {
+		node := &doc.Children[i]
+		switch node.XMLName.Local {
+		case "simpleType":
+			if pattern := restrictionPattern(node); pattern != "" {
+				xsd.patterns[node.attr("name")] = pattern
+			}
+		case "element":
+			if xsd.root != nil {
+				return nil, errors.New("invalid XSD: more than one root element")
+			}
+			xsd.root = flattenXSDElement(node)
+		}
+	}
+	if xsd.root == nil {
+		return nil, errors.New("invalid XSD: no root element")
+	}
+	return xsd, nil
+}
+
+// flattenXSDElement collects the child elements and attributes of an
+// element declaration, looking through sequence, choice, all and
+// simpleContent groups
+func flattenXSDElement(node *xsdNode) *xsdElement {
+	decl := &xsdElement{
+		name:       node.attr("name"),
+		textType:   node.attr("type"),
+		children:   make(map[string]*xsdElement),
+		attributes: make(map[string]xsdAttribute),
+	}
+
+	var walk func(n *xsdNode)
+	walk = func(n *xsdNode) {
+		for i := range n.Children {
+			child := &n.Children[i]
+			switch child.XMLName.Local {
+			case "element":
+				nested := flattenXSDElement(child)
+				decl.children[nested.name] = nested
+			case "attribute":
+				attr := xsdAttribute{typ: child.attr("type"), required: child.attr("use") == "required"}
+				for j := range child.Children {
+					if child.Children[j].XMLName.Local == "simpleType" {
+						attr.pattern = restrictionPattern(&child.Children[j])
+					}
+				}
+				decl.attributes[child.attr("name")] = attr
+			case "complexType":
+				decl.mixed = child.attr("mixed") == "true"
+				walk(child)
+			case "extension":
+				// Text with attributes: simpleContent extending a text type
+				decl.textType = child.attr("base")
+				walk(child)
+			case "sequence", "choice", "all", "simpleContent":
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+	return decl
+}
+
+func restrictionPattern(simpleType *xsdNode) string {
+	for i := range simpleType.Children {
+		restriction := &simpleType.Children[i]
+		for j := range restriction.Children {
+			if restriction.Children[j].XMLName.Local == "pattern" {
+				return restriction.Children[j].attr("value")
+			}
+		}
+	}
+	return ""
+}
+
+func (xsd *xsdSchema) checkValue(typ, pattern, value string) error {
+	if _, local, prefixed := strings.Cut(typ, ":"); prefixed {
+		typ = local
+	}
+	if named, ok := xsd.patterns[typ]; ok {
+		pattern = named
+	}
+
+	switch typ {
+	case "nonNegativeInteger":
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return fmt.Errorf("%q is not a non-negative integer", value)
+		}
+	case "decimal":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%q is not a decimal", value)
+		}
+	case "boolean":
+		switch value {
+		case "true", "false", "1", "0":
+		default:
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+	}
+
+	if pattern != "" {
+		// XSD patterns are implicitly anchored
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("schema: invalid pattern %q: %w", pattern, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%q does not match %s", value, pattern)
+		}
+	}
+	return nil
+}
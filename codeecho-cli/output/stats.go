@@ -0,0 +1,143 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
+)
+
+// processingOptions names the content processing a scan applied
+// The XML header and every footer use these names; pack readers match them
+func processingOptions(opts types.OutputOptions) []string {
+	var options []string
+	if opts.RemoveComments {
+		options = append(options, "comments removed")
+	}
+	if opts.RemoveEmptyLines {
+		options = append(options, "empty lines removed")
+	}
+	if opts.CompressCode {
+		options = append(options, "code compressed")
+	}
+	if opts.SampleDataFiles {
+		options = append(options, "large data files sampled")
+	}
+	return options
+}
+
+// languageTotals is one row of the language breakdown
+type languageTotals struct {
+	ID   string // e.g. "go"
+	Name string // e.g. "Go"
+	scanner.LanguageStat
+}
+
+// sortedLanguages returns the language breakdown, most files first
+func sortedLanguages(stats *scanner.StreamingStats) []languageTotals {
+	rows := make([]languageTotals, 0, len(stats.LanguageCounts))
+	for lang, count := range stats.LanguageCounts {
+		row := languageTotals{ID: lang, Name: scanner.LanguageDisplayName(lang)}
+		// Stats built by hand may only have counts
+		if totals := stats.Languages[lang]; totals != nil {
+			row.LanguageStat = *totals
+		} else {
+			row.Files = count
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Files != rows[j].Files {
+			return rows[i].Files > rows[j].Files
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	return rows
+}
+
+// phaseErrors holds the errors of one scan phase ("read", "write", ...)
+type phaseErrors struct {
+	Phase  string
+	Errors []scanner.ScanError
+}
+
+// errorsByPhase groups errors by phase, in phase order, keeping the order
+// they were recorded in within a phase
+func errorsByPhase(errors []scanner.ScanError) []phaseErrors {
+	var groups []phaseErrors
+	index := make(map[string]int)
+	for _, scanErr := range errors {
+		i, ok := index[scanErr.Phase]
+		if !ok {
+			i = len(groups)
+			index[scanErr.Phase] = i
+			groups = append(groups, phaseErrors{Phase: scanErr.Phase})
+		}
+		groups[i].Errors = append(groups[i].Errors, scanErr)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Phase < groups[j].Phase
+	})
+	return groups
+}
+
+// statisticsText renders the footer statistics as plain lines
+// Shared by the text format and the claude-xml statistics document
+func statisticsText(stats *scanner.StreamingStats, opts types.OutputOptions) string {
+	processing := "none"
+	if options := processingOptions(opts); len(options) > 0 {
+		processing = strings.Join(options, ", ")
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Files: %d (%d text, %d binary), %s\n",
+		stats.TotalFiles, stats.TextFiles, stats.BinaryFiles, utils.FormatBytes(stats.TotalSize)))
+	b.WriteString(fmt.Sprintf("Lines: %d, tokens: ~%d\n", stats.TotalLines, stats.TotalTokens))
+	b.WriteString(fmt.Sprintf("Duration: %s\nProcessing: %s\n", utils.FormatDuration(stats.Duration), processing))
+
+	if languages := sortedLanguages(stats); len(languages) > 0 {
+		b.WriteString("Languages:\n")
+		for _, lang := range languages {
+			b.WriteString(fmt.Sprintf("  %-20s %d files, %d lines, ~%d tokens, %s\n",
+				lang.Name, lang.Files, lang.Lines, lang.Tokens, utils.FormatBytes(lang.Size)))
+		}
+	}
+
+	if len(stats.Skipped) > 0 {
+		b.WriteString(fmt.Sprintf("Skipped (%d):\n", len(stats.Skipped)))
+		for _, skipped := range stats.Skipped {
+			b.WriteString(fmt.Sprintf("  %s: %s\n", skipped.Path, skipped.Reason))
+		}
+	}
+
+	if len(stats.Errors) > 0 {
+		b.WriteString(fmt.Sprintf("Errors (%d):\n", len(stats.Errors)))
+		for _, group := range errorsByPhase(stats.Errors) {
+			b.WriteString(fmt.Sprintf("  %s (%d):\n", group.Phase, len(group.Errors)))
+			for _, scanErr := range group.Errors {
+				b.WriteString(fmt.Sprintf("    %s: %s\n", scanErr.Path, describeError(scanErr)))
+			}
+		}
+	}
+	return b.String()
+}
+
+// errorMessage is the text of a scan error
+func errorMessage(scanErr scanner.ScanError) string {
+	if scanErr.Error == nil {
+		return ""
+	}
+	return scanErr.Error.Error()
+}
+
+// describeError is the message for human-readable footers, noting when
+// the error left the file out of the output
+func describeError(scanErr scanner.ScanError) string {
+	if scanErr.Skipped {
+		return errorMessage(scanErr) + " (skipped)"
+	}
+	return errorMessage(scanErr)
+}
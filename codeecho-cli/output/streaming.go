@@ -6,12 +6,13 @@ import (
 
 	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
 	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
 )
 
 type StreamingWriter interface {
 	WriteHeader(repoPath string, scanTime string) error
 	WriteGitMetadata(git *scanner.GitMetadata) error
-	WriteTree(paths []string) error
+	WriteTree(tree *scanner.Tree) error
 	WriteFile(file *scanner.FileInfo) error
 	WriteFooter(stats *scanner.StreamingStats) error
 	Close() error
@@ -19,12 +20,72 @@ type StreamingWriter interface {
 
 // NewStreamingWriter creates the appropriate writer based on format
 // Factory pattern - returns different implementations of same interface
+// With opts.Compression the output is compressed below the writer's buffer
 func NewStreamingWriter(w io.Writer, format string, opts types.OutputOptions) (StreamingWriter, error) {
+	if opts.Compression == "" || opts.Compression == utils.CompressNone {
+		return newFormatWriter(w, format, opts)
+	}
+
+	compressor, err := utils.NewCompressor(w, opts.Compression)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := newFormatWriter(compressor, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &compressedWriter{StreamingWriter: writer, compressor: compressor}, nil
+}
+
+// compressedWriter closes the compressed stream after the format writer
+// has flushed into it
+type compressedWriter struct {
+	StreamingWriter
+	compressor io.Closer
+}
+
+func (w *compressedWriter) Close() error {
+	err := w.StreamingWriter.Close()
+	if closeErr := w.compressor.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// CanonicalFormat resolves a format alias ("md", "txt") to its name and
+// reports whether the format is supported
+func CanonicalFormat(format string) (string, bool) {
+	switch format {
+	case "md":
+		return "markdown", true
+	case "txt":
+		return "text", true
+	case "xml", "json", "jsonl", "claude-xml", "chunks", "sqlite", "html", "text", "template", "markdown":
+		return format, true
+	}
+	return format, false
+}
+
+func newFormatWriter(w io.Writer, format string, opts types.OutputOptions) (StreamingWriter, error) {
 	switch format {
 	case "xml":
 		return NewStreamingXMLWriter(w, opts), nil
 	case "json":
 		return NewStreamingJSONWriter(w, opts), nil
+	case "jsonl":
+		return NewStreamingJSONLWriter(w, opts), nil
+	case "claude-xml":
+		return NewStreamingClaudeXMLWriter(w, opts), nil
+	case "chunks":
+		return NewStreamingChunksWriter(w, opts), nil
+	case "sqlite":
+		return NewStreamingSQLiteWriter(w, opts)
+	case "html":
+		return NewStreamingHTMLWriter(w, opts), nil
+	case "text", "txt":
+		return NewStreamingTextWriter(w, opts), nil
+	case "template":
+		return NewStreamingTemplateWriter(w, opts)
 	case "markdown", "md":
 		return NewStreamingMarkdownWriter(w, opts), nil
 	default:
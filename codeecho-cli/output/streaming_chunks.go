@@ -0,0 +1,81 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+)
+
+// StreamingChunksWriter writes one JSON record per chunk, one per line
+// Why: Vector stores index passages, not files. Each record carries
+// everything an upsert needs (stable ID, location, symbol, text), so
+// the output can be loaded without further processing.
+type StreamingChunksWriter struct {
+	writer    *bufio.Writer
+	encoder   *json.Encoder
+	opts      types.OutputOptions
+	maxTokens int
+	overlap   int
+}
+
+func NewStreamingChunksWriter(w io.Writer, opts types.OutputOptions) *StreamingChunksWriter {
+	writer := bufio.NewWriterSize(w, 65536)
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+
+	maxTokens := opts.ChunkTokens
+	if maxTokens <= 0 {
+		maxTokens = scanner.DefaultChunkTokens
+	}
+
+	return &StreamingChunksWriter{
+		writer:    writer,
+		encoder:   encoder,
+		opts:      opts,
+		maxTokens: maxTokens,
+		overlap:   opts.ChunkOverlap,
+	}
+}
+
+// Chunk records stand alone, so repository metadata and the tree are not written
+func (w *StreamingChunksWriter) WriteHeader(repoPath string, scanTime string) error {
+	return nil
+}
+
+func (w *StreamingChunksWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
+	return nil
+}
+
+func (w *StreamingChunksWriter) WriteTree(tree *scanner.Tree) error {
+	return nil
+}
+
+// chunkRecord is one line of the output
+// Records are read one at a time, so each carries the schema version
+type chunkRecord struct {
+	SchemaVersion string `json:"schema_version"`
+	scanner.Chunk
+}
+
+func (w *StreamingChunksWriter) WriteFile(file *scanner.FileInfo) error {
+	for _, chunk := range scanner.ChunkFile(file, w.maxTokens, w.overlap) {
+		if !w.opts.IncludeContent {
+			chunk.Content = ""
+		}
+		if err := w.encoder.Encode(chunkRecord{SchemaVersion: SchemaVersion, Chunk: chunk}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *StreamingChunksWriter) WriteFooter(stats *scanner.StreamingStats) error {
+	return nil
+}
+
+func (w *StreamingChunksWriter) Close() error {
+	return w.writer.Flush()
+}
@@ -0,0 +1,182 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+)
+
+// StreamingClaudeXMLWriter writes the <documents> layout recommended in
+// Anthropic's long-context prompting guide
+// Why: Models are trained to cite and compare documents in this shape, and
+// CDATA keeps code readable instead of entity-escaping every < and &
+type StreamingClaudeXMLWriter struct {
+	writer *bufio.Writer
+	opts   types.OutputOptions
+	index  int // Last document index written
+
+	// The leading repository document collects header, git and tree first
+	repoPath       string
+	scanTime       string
+	git            *scanner.GitMetadata
+	tree           string
+	leadingWritten bool
+}
+
+func NewStreamingClaudeXMLWriter(w io.Writer, opts types.OutputOptions) *StreamingClaudeXMLWriter {
+	return &StreamingClaudeXMLWriter{
+		writer: bufio.NewWriterSize(w, 65536),
+		opts:   opts,
+	}
+}
+
+func (w *StreamingClaudeXMLWriter) WriteHeader(repoPath string, scanTime string) error {
+	w.repoPath = repoPath
+	w.scanTime = scanTime
+	_, err := w.writer.WriteString("<documents>\n")
+	return err
+}
+
+func (w *StreamingClaudeXMLWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
+	w.git = git
+	return nil
+}
+
+func (w *StreamingClaudeXMLWriter) WriteTree(tree *scanner.Tree) error {
+	if !w.opts.IncludeDirectoryTree || len(tree.Files) == 0 {
+		return nil
+	}
+	w.tree = RenderTree(tree, w.opts)
+	return nil
+}
+
+// writeLeadingDocument writes repository metadata and the tree as document 1
+func (w *StreamingClaudeXMLWriter) writeLeadingDocument() error {
+	if w.leadingWritten {
+		return nil
+	}
+	w.leadingWritten = true
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("Repository: %s\n", w.repoPath))
+	content.WriteString(fmt.Sprintf("Scan time: %s\n", w.scanTime))
+	content.WriteString(fmt.Sprintf("Schema version: %s\n", SchemaVersion))
+	if w.git != nil {
+		if w.git.Branch != "" {
+			content.WriteString(fmt.Sprintf("Branch: %s\n", w.git.Branch))
+		}
+		if w.git.CommitHash != "" {
+			content.WriteString(fmt.Sprintf("Commit: %s\n", w.git.CommitHash))
+		}
+		if w.git.Author != "" {
+			content.WriteString(fmt.Sprintf("Author: %s\n", w.git.Author))
+		}
+		if w.git.CommitDate != "" {
+			content.WriteString(fmt.Sprintf("Commit date: %s\n", w.git.CommitDate))
+		}
+	}
+	if w.tree != "" {
+		content.WriteString("\nDirectory structure:\n")
+		content.WriteString(w.tree)
+	}
+
+	return w.writeDocument("repository_metadata", ClaudeDocumentRepository, nil, content.String())
+}
+
+// Values of the kind attribute on documents that are not repository files
+// Why: A repository may hold a file named repository_metadata, so the
+// source alone cannot tell the two apart
+const (
+	ClaudeDocumentRepository = "repository"
+	ClaudeDocumentStatistics = "statistics"
+	ClaudeDocumentManifest   = "manifest"
+)
+
+// writeDocument writes one <document>; metadata children are written in order
+// kind is empty for files
+func (w *StreamingClaudeXMLWriter) writeDocument(source, kind string, metadata [][2]string, content string) error {
+	w.index++
+
+	var doc strings.Builder
+	if kind != "" {
+		doc.WriteString(fmt.Sprintf("<document index=\"%d\" kind=\"%s\">\n", w.index, kind))
+	} else {
+		doc.WriteString(fmt.Sprintf("<document index=\"%d\">\n", w.index))
+	}
+	doc.WriteString(fmt.Sprintf("<source>%s</source>\n", escapeXML(source)))
+	for _, field := range metadata {
+		doc.WriteString(fmt.Sprintf("<%s>%s</%s>\n", field[0], escapeXML(field[1]), field[0]))
+	}
+	doc.WriteString("<document_content>")
+	doc.WriteString(cdata(content))
+	doc.WriteString("</document_content>\n</document>\n")
+
+	_, err := w.writer.WriteString(doc.String())
+	return err
+}
+
+func (w *StreamingClaudeXMLWriter) WriteFile(file *scanner.FileInfo) error {
+	if err := w.writeLeadingDocument(); err != nil {
+		return err
+	}
+
+	var metadata [][2]string
+	if file.Language != "" {
+		metadata = append(metadata, [2]string{"language", scanner.LanguageDisplayName(file.Language)})
+	}
+	if file.LineCount > 0 {
+		metadata = append(metadata, [2]string{"line_count", fmt.Sprintf("%d", file.LineCount)})
+	}
+	if file.Flattened {
+		metadata = append(metadata, [2]string{"flattened", "true"})
+	}
+	if file.SHA256 != "" {
+		// Lets apply tell whether the file changed since the pack was made
+		metadata = append(metadata, [2]string{"sha256", file.SHA256})
+	}
+	if file.ContentSHA256 != "" {
+		metadata = append(metadata, [2]string{"content_sha256", file.ContentSHA256})
+	}
+
+	content := ""
+	switch {
+	case !file.IsText:
+		content = "Binary file - content not included"
+		if file.Binary != nil && file.Binary.Format != "" {
+			content = fmt.Sprintf("Binary file (%s) - content not included", file.Binary.Format)
+		}
+	case w.opts.IncludeContent && w.opts.ShowLineNumbers:
+		content = addLineNumbers(file.Content)
+	case w.opts.IncludeContent:
+		content = file.Content
+	}
+
+	return w.writeDocument(file.RelativePath, "", metadata, content)
+}
+
+func (w *StreamingClaudeXMLWriter) WriteFooter(stats *scanner.StreamingStats) error {
+	if err := w.writeLeadingDocument(); err != nil {
+		return err
+	}
+
+	if err := w.writeDocument("scan_statistics", ClaudeDocumentStatistics, nil, statisticsText(stats, w.opts)); err != nil {
+		return err
+	}
+
+	// The digest covers every file, so it can only come last
+	manifest := fmt.Sprintf("Files: %d\nDigest: %s\n", stats.TotalFiles, stats.ManifestDigest)
+	if err := w.writeDocument("repository_manifest", ClaudeDocumentManifest, nil, manifest); err != nil {
+		return err
+	}
+
+	_, err := w.writer.WriteString("</documents>\n")
+	return err
+}
+
+func (w *StreamingClaudeXMLWriter) Close() error {
+	return w.writer.Flush()
+}
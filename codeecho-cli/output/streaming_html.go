@@ -0,0 +1,387 @@
+package output
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
+)
+
+// Why: The report must open offline and from an email attachment, so the
+// stylesheet and highlighter are inlined rather than loaded from a CDN
+var (
+	//go:embed assets/report.css
+	reportCSS string
+	//go:embed assets/report.js
+	reportJS string
+)
+
+// StreamingHTMLWriter writes a self-contained HTML report
+// File contents are written as they arrive; only a path, size and token
+// count per file are kept for the heatmap, so memory stays flat on large
+// repositories. The summary is written last and moved to the top with CSS.
+type StreamingHTMLWriter struct {
+	writer *bufio.Writer
+	opts   types.OutputOptions
+	heat   []heatCell
+	files  bool // The files section has been opened
+}
+
+// heatCell is what the heatmap needs to remember about a file
+type heatCell struct {
+	path   string
+	size   int64
+	tokens int
+}
+
+func NewStreamingHTMLWriter(w io.Writer, opts types.OutputOptions) *StreamingHTMLWriter {
+	return &StreamingHTMLWriter{
+		writer: bufio.NewWriterSize(w, 65536),
+		opts:   opts,
+	}
+}
+
+func (w *StreamingHTMLWriter) WriteHeader(repoPath string, scanTime string) error {
+	name := html.EscapeString(filepath.Base(repoPath))
+	page := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="CodeEcho CLI">
+<meta name="codeecho-schema-version" content="%s">
+<title>CodeEcho report: %s</title>
+<style>
+%s</style>
+</head>
+<body>
+<header>
+<h1>%s</h1>
+<p>%s &middot; scanned %s</p>
+</header>
+<main>
+`, SchemaVersion, name, reportCSS, name, html.EscapeString(repoPath), html.EscapeString(scanTime))
+
+	_, err := w.writer.WriteString(page)
+	return err
+}
+
+func (w *StreamingHTMLWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
+	if git == nil {
+		return nil
+	}
+
+	var panel strings.Builder
+	panel.WriteString("<section id=\"git\" class=\"panel\">\n<h2>Git</h2>\n<dl>\n")
+	fields := [][2]string{
+		{"Branch", git.Branch},
+		{"Commit", git.CommitHash},
+		{"Author", git.Author},
+		{"Date", git.CommitDate},
+	}
+	for _, field := range fields {
+		if field[1] != "" {
+			panel.WriteString(fmt.Sprintf("<dt>%s</dt><dd>%s</dd>\n", field[0], html.EscapeString(field[1])))
+		}
+	}
+	if git.CommitCount > 0 {
+		panel.WriteString(fmt.Sprintf("<dt>Commits</dt><dd>%d</dd>\n", git.CommitCount))
+	}
+	panel.WriteString("</dl>\n</section>\n")
+
+	_, err := w.writer.WriteString(panel.String())
+	return err
+}
+
+// htmlTreeNode is a directory in the collapsible tree
+type htmlTreeNode struct {
+	dirs  map[string]*htmlTreeNode
+	files []string // Full relative paths, for anchors
+}
+
+func (w *StreamingHTMLWriter) WriteTree(tree *scanner.Tree) error {
+	if !w.opts.IncludeDirectoryTree || len(tree.Files) == 0 {
+		return nil
+	}
+
+	root := &htmlTreeNode{dirs: make(map[string]*htmlTreeNode)}
+	for _, path := range tree.Paths() {
+		node := root
+		parts := strings.Split(filepath.ToSlash(path), "/")
+		for _, dir := range parts[:len(parts)-1] {
+			child, ok := node.dirs[dir]
+			if !ok {
+				child = &htmlTreeNode{dirs: make(map[string]*htmlTreeNode)}
+				node.dirs[dir] = child
+			}
+			node = child
+		}
+		node.files = append(node.files, path)
+	}
+
+	var section strings.Builder
+	section.WriteString(fmt.Sprintf("<section id=\"tree\" class=\"panel\">\n<h2>Directory structure (%d files)</h2>\n", len(tree.Files)))
+	writeHTMLTree(&section, root, 0)
+	section.WriteString("</section>\n")
+
+	_, err := w.writer.WriteString(section.String())
+	return err
+}
+
+// writeHTMLTree renders directories as nested <details>, top level open
+func writeHTMLTree(b *strings.Builder, node *htmlTreeNode, depth int) {
+	b.WriteString("<ul>\n")
+
+	dirs := make([]string, 0, len(node.dirs))
+	for dir := range node.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		open := ""
+		if depth == 0 {
+			open = " open"
+		}
+		b.WriteString(fmt.Sprintf("<li><details%s><summary>%s/</summary>\n", open, html.EscapeString(dir)))
+		writeHTMLTree(b, node.dirs[dir], depth+1)
+		b.WriteString("</details></li>\n")
+	}
+
+	sort.Strings(node.files)
+	for _, path := range node.files {
+		b.WriteString(fmt.Sprintf("<li><a href=\"#%s\">%s</a></li>\n", fileAnchor(path), html.EscapeString(filepath.Base(path))))
+	}
+
+	b.WriteString("</ul>\n")
+}
+
+// fileAnchor gives each path a short id that is safe in a URL fragment
+func fileAnchor(path string) string {
+	h := fnv.New64a()
+	h.Write([]byte(filepath.ToSlash(path)))
+	return fmt.Sprintf("f-%x", h.Sum64())
+}
+
+func (w *StreamingHTMLWriter) WriteFile(file *scanner.FileInfo) error {
+	if !w.files {
+		w.files = true
+		if _, err := w.writer.WriteString("<section id=\"files\">\n<h2>Files</h2>\n"); err != nil {
+			return err
+		}
+	}
+	w.heat = append(w.heat, heatCell{path: file.RelativePath, size: file.Size, tokens: file.TokenCount})
+
+	var meta []string
+	if file.Language != "" {
+		meta = append(meta, scanner.LanguageDisplayName(file.Language))
+	}
+	if file.LineCount > 0 {
+		meta = append(meta, fmt.Sprintf("%d lines", file.LineCount))
+	}
+	meta = append(meta, utils.FormatBytes(file.Size))
+	if file.TokenCount > 0 {
+		meta = append(meta, fmt.Sprintf("~%d tokens", file.TokenCount))
+	}
+	if file.Category != "" && file.Category != scanner.CategorySource {
+		meta = append(meta, file.Category)
+	}
+	if file.Sampled {
+		meta = append(meta, fmt.Sprintf("sampled from %d lines", file.OriginalLineCount))
+	}
+	if file.Flattened {
+		meta = append(meta, "flattened notebook")
+	}
+	if width, height := textImageSize(file); width > 0 {
+		meta = append(meta, fmt.Sprintf("%dx%d", width, height))
+	}
+
+	var entry strings.Builder
+	entry.WriteString(fmt.Sprintf("<details class=\"file\" id=\"%s\"%s><summary><span class=\"path\">%s</span><span class=\"meta\">%s</span></summary>\n",
+		fileAnchor(file.RelativePath), hashAttributes(file), html.EscapeString(file.RelativePath), html.EscapeString(strings.Join(meta, " · "))))
+
+	switch {
+	case !file.IsText:
+		note := "Binary file - content not included"
+		if file.Binary != nil && file.Binary.Format != "" {
+			note = fmt.Sprintf("Binary file (%s) - content not included", file.Binary.Format)
+		}
+		entry.WriteString(fmt.Sprintf("<p class=\"note\">%s</p>\n", html.EscapeString(note)))
+	case !w.opts.IncludeContent || file.Content == "":
+		entry.WriteString("<p class=\"note\">Content not included</p>\n")
+	default:
+		content := file.Content
+		if w.opts.ShowLineNumbers {
+			content = addLineNumbers(content)
+		}
+		entry.WriteString(fmt.Sprintf("<pre><code%s>", highlightAttributes(file.Language)))
+		entry.WriteString(html.EscapeString(content))
+		entry.WriteString("</code></pre>\n")
+	}
+	entry.WriteString("</details>\n")
+
+	_, err := w.writer.WriteString(entry.String())
+	return err
+}
+
+// hashAttributes records the file's hashes for tools reading the report
+func hashAttributes(file *scanner.FileInfo) string {
+	attributes := ""
+	if file.SHA256 != "" {
+		attributes += fmt.Sprintf(" data-sha256=\"%s\"", file.SHA256)
+	}
+	if file.ContentSHA256 != "" {
+		attributes += fmt.Sprintf(" data-content-sha256=\"%s\"", file.ContentSHA256)
+	}
+	return attributes
+}
+
+// highlightAttributes passes the language's comment markers to the
+// embedded highlighter, which has no language tables of its own
+func highlightAttributes(language string) string {
+	lang, ok := scanner.LookupLanguage(language)
+	if !ok {
+		return ""
+	}
+
+	var blocks []string
+	for _, pair := range lang.BlockComments {
+		if len(pair) == 2 {
+			blocks = append(blocks, pair[0], pair[1])
+		}
+	}
+	return fmt.Sprintf(` data-lang="%s" data-lc="%s" data-bc="%s"`,
+		html.EscapeString(lang.ID),
+		html.EscapeString(strings.Join(lang.LineComments, " ")),
+		html.EscapeString(strings.Join(blocks, " ")))
+}
+
+func (w *StreamingHTMLWriter) WriteFooter(stats *scanner.StreamingStats) error {
+	var b strings.Builder
+	if w.files {
+		b.WriteString("</section>\n")
+	}
+
+	b.WriteString("<section id=\"summary\" class=\"grid\">\n")
+
+	// Totals
+	b.WriteString("<div class=\"panel\">\n<h2>Summary</h2>\n<dl>\n")
+	b.WriteString(fmt.Sprintf("<dt>Files</dt><dd>%d (%d text, %d binary)</dd>\n", stats.TotalFiles, stats.TextFiles, stats.BinaryFiles))
+	b.WriteString(fmt.Sprintf("<dt>Size</dt><dd>%s</dd>\n", utils.FormatBytes(stats.TotalSize)))
+	b.WriteString(fmt.Sprintf("<dt>Lines</dt><dd>%d</dd>\n", stats.TotalLines))
+	b.WriteString(fmt.Sprintf("<dt>Tokens</dt><dd>~%d</dd>\n", stats.TotalTokens))
+	b.WriteString(fmt.Sprintf("<dt>Duration</dt><dd>%s</dd>\n", utils.FormatDuration(stats.Duration)))
+	processing := "none"
+	if options := processingOptions(w.opts); len(options) > 0 {
+		processing = strings.Join(options, ", ")
+	}
+	b.WriteString(fmt.Sprintf("<dt>Processing</dt><dd>%s</dd>\n", html.EscapeString(processing)))
+	b.WriteString(fmt.Sprintf("<dt>Manifest</dt><dd><code>%s</code></dd>\n", stats.ManifestDigest))
+	b.WriteString(fmt.Sprintf("<dt>Skipped</dt><dd>%d</dd>\n", len(stats.Skipped)))
+	b.WriteString(fmt.Sprintf("<dt>Errors</dt><dd>%d</dd>\n", len(stats.Errors)))
+	b.WriteString("</dl>\n</div>\n")
+
+	writeLanguageChart(&b, stats)
+	w.writeHeatmap(&b)
+	writeSkippedList(&b, stats.Skipped)
+	writeErrorList(&b, stats.Errors)
+
+	b.WriteString("</section>\n</main>\n<script>\n")
+	b.WriteString(reportJS)
+	b.WriteString("</script>\n</body>\n</html>\n")
+
+	_, err := w.writer.WriteString(b.String())
+	return err
+}
+
+// writeLanguageChart draws a bar per language, most files first
+func writeLanguageChart(b *strings.Builder, stats *scanner.StreamingStats) {
+	languages := sortedLanguages(stats)
+	if len(languages) == 0 {
+		return
+	}
+	top := languages[0].Files
+
+	b.WriteString("<div class=\"panel\">\n<h2>Languages</h2>\n<ul class=\"bars\">\n")
+	for _, lang := range languages {
+		title := fmt.Sprintf("%d files, %d lines, ~%d tokens, %s", lang.Files, lang.Lines, lang.Tokens, utils.FormatBytes(lang.Size))
+		b.WriteString(fmt.Sprintf("<li title=\"%s\"><span>%s</span><span class=\"bar\" style=\"width:%.1f%%\"></span><span class=\"n\">%d</span></li>\n",
+			html.EscapeString(title), html.EscapeString(lang.Name), float64(lang.Files)*100/float64(top), lang.Files))
+	}
+	b.WriteString("</ul>\n</div>\n")
+}
+
+// writeHeatmap draws one cell per file, shaded by tokens or size
+// A log scale keeps a few huge files from washing out everything else
+func (w *StreamingHTMLWriter) writeHeatmap(b *strings.Builder) {
+	if len(w.heat) == 0 {
+		return
+	}
+
+	maxTokens, maxSize := 1.0, 1.0
+	for _, cell := range w.heat {
+		maxTokens = math.Max(maxTokens, float64(cell.tokens))
+		maxSize = math.Max(maxSize, float64(cell.size))
+	}
+	scale := func(v, max float64) float64 {
+		return math.Log1p(v) / math.Log1p(max)
+	}
+
+	b.WriteString("<div class=\"panel\">\n<h2>Heatmap by <span id=\"heat-metric\">tokens</span><button id=\"heat-toggle\" class=\"toggle\" type=\"button\">Show size</button></h2>\n<div id=\"heatmap\" class=\"heatmap\">\n")
+	for _, cell := range w.heat {
+		title := fmt.Sprintf("%s: ~%d tokens, %s", cell.path, cell.tokens, utils.FormatBytes(cell.size))
+		b.WriteString(fmt.Sprintf("<a href=\"#%s\" title=\"%s\" style=\"--t:%.2f;--s:%.2f\"></a>",
+			fileAnchor(cell.path), html.EscapeString(title),
+			scale(float64(cell.tokens), maxTokens), scale(float64(cell.size), maxSize)))
+	}
+	b.WriteString("\n</div>\n</div>\n")
+}
+
+// writeErrorList lists what the scan recorded but couldn't handle
+func writeErrorList(b *strings.Builder, errors []scanner.ScanError) {
+	if len(errors) == 0 {
+		return
+	}
+
+	groups := errorsByPhase(errors)
+	counts := make([]string, len(groups))
+	for i, group := range groups {
+		counts[i] = fmt.Sprintf("%s: %d", group.Phase, len(group.Errors))
+	}
+
+	b.WriteString(fmt.Sprintf("<div class=\"panel\" id=\"errors\">\n<h2>Scan errors (%d)</h2>\n<p>%s</p>\n<table>\n<tr><th>Path</th><th>Phase</th><th>Error</th></tr>\n",
+		len(errors), html.EscapeString(strings.Join(counts, " · "))))
+	for _, group := range groups {
+		for _, scanErr := range group.Errors {
+			b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				html.EscapeString(scanErr.Path), html.EscapeString(scanErr.Phase), html.EscapeString(describeError(scanErr))))
+		}
+	}
+	b.WriteString("</table>\n</div>\n")
+}
+
+// writeSkippedList lists files left out on purpose, with the reason
+func writeSkippedList(b *strings.Builder, skipped []scanner.SkippedFile) {
+	if len(skipped) == 0 {
+		return
+	}
+
+	b.WriteString(fmt.Sprintf("<div class=\"panel\" id=\"skipped\">\n<h2>Skipped files (%d)</h2>\n<table>\n<tr><th>Path</th><th>Reason</th></tr>\n", len(skipped)))
+	for _, file := range skipped {
+		b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td></tr>\n", html.EscapeString(file.Path), html.EscapeString(file.Reason)))
+	}
+	b.WriteString("</table>\n</div>\n")
+}
+
+func (w *StreamingHTMLWriter) Close() error {
+	return w.writer.Flush()
+}
@@ -15,17 +15,16 @@ import (
 type StreamingJSONWriter struct {
 	writer    *bufio.Writer
 	opts      types.OutputOptions
-	stats     *scanner.StreamingStats
 	firstFile bool // Track if this is the first file (for comma handling)
+
+	// The files array opens on the first file, after the optional tree field
+	filesOpened bool
 }
 
 func NewStreamingJSONWriter(w io.Writer, opts types.OutputOptions) *StreamingJSONWriter {
 	return &StreamingJSONWriter{
-		writer: bufio.NewWriterSize(w, 65536),
-		opts:   opts,
-		stats: &scanner.StreamingStats{
-			LanguageCounts: make(map[string]int),
-		},
+		writer:    bufio.NewWriterSize(w, 65536),
+		opts:      opts,
 		firstFile: true,
 	}
 }
@@ -37,10 +36,11 @@ func (w *StreamingJSONWriter) WriteHeader(repoPath string, scanTime string) erro
 	}
 
 	// Write repo metadata
-	repoInfo := fmt.Sprintf(`  "repo_path": %s,
+	repoInfo := fmt.Sprintf(`  "schema_version": %s,
+  "repo_path": %s,
   "scan_time": %s,
   "processed_by": "CodeEcho CLI",
-`, jsonString(repoPath), jsonString(scanTime))
+`, jsonString(SchemaVersion), jsonString(repoPath), jsonString(scanTime))
 
 	if _, err := w.writer.WriteString(repoInfo); err != nil {
 		return err
@@ -52,10 +52,6 @@ func (w *StreamingJSONWriter) WriteHeader(repoPath string, scanTime string) erro
 func (w *StreamingJSONWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
 	if git == nil {
 		// Continue without git section
-		if _, err := w.writer.WriteString(`  "files": [
-`); err != nil {
-			return err
-		}
 		return nil
 	}
 
@@ -75,31 +71,19 @@ func (w *StreamingJSONWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
 		return err
 	}
 
-	// Start files array
-	if _, err := w.writer.WriteString(`  "files": [
-`); err != nil {
-		return err
-	}
-
 	return nil
 }
 
-func (w *StreamingJSONWriter) WriteTree(paths []string) error {
-	if !w.opts.IncludeDirectoryTree || len(paths) == 0 {
+func (w *StreamingJSONWriter) WriteTree(tree *scanner.Tree) error {
+	if !w.opts.IncludeDirectoryTree || len(tree.Files) == 0 {
 		return nil
 	}
 
-	// Convert paths to FileInfo structs (minimal data needed for tree)
-	fileInfos := make([]scanner.FileInfo, len(paths))
-	for i, path := range paths {
-		fileInfos[i] = scanner.FileInfo{RelativePath: path}
-	}
-
-	tree := GenerateDirectoryTree(fileInfos)
+	rendered := RenderTree(tree, w.opts)
 
 	// Add tree field before files array
 	treeField := fmt.Sprintf(`  "directory_tree": %s,
-`, jsonString(tree))
+`, jsonString(rendered))
 
 	if _, err := w.writer.WriteString(treeField); err != nil {
 		return err
@@ -108,19 +92,22 @@ func (w *StreamingJSONWriter) WriteTree(paths []string) error {
 	return nil
 }
 
-func (w *StreamingJSONWriter) WriteFile(file *scanner.FileInfo) error {
-	// Update stats
-	w.stats.TotalFiles++
-	w.stats.TotalSize += file.Size
-
-	if file.IsText {
-		w.stats.TextFiles++
-	} else {
-		w.stats.BinaryFiles++
+// openFiles starts the files array once
+// Why: WriteTree runs after WriteGitMetadata, so opening the array there
+// put "directory_tree" inside it and broke the JSON
+func (w *StreamingJSONWriter) openFiles() error {
+	if w.filesOpened {
+		return nil
 	}
+	w.filesOpened = true
+	_, err := w.writer.WriteString(`  "files": [
+`)
+	return err
+}
 
-	if file.Language != "" {
-		w.stats.LanguageCounts[file.Language]++
+func (w *StreamingJSONWriter) WriteFile(file *scanner.FileInfo) error {
+	if err := w.openFiles(); err != nil {
+		return err
 	}
 
 	// Add comma before all files except the first
@@ -150,28 +137,114 @@ func (w *StreamingJSONWriter) WriteFile(file *scanner.FileInfo) error {
 }
 
 func (w *StreamingJSONWriter) WriteFooter(stats *scanner.StreamingStats) error {
+	if err := w.openFiles(); err != nil {
+		return err
+	}
+
 	// Close files array
 	if _, err := w.writer.WriteString("\n  ],\n"); err != nil {
 		return err
 	}
 
 	// Write statistics
-	statsJSON := fmt.Sprintf(`  "statistics": {
-    "total_files": %d,
-    "total_size": %s,
-    "text_files": %d,
-    "binary_files": %d
+	statsJSON, err := json.MarshalIndent(newJSONStatistics(stats, w.opts), "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	footer := fmt.Sprintf(`  "statistics": %s,
+  "manifest": {
+    "files": %d,
+    "digest": %s
   }
 }
-`, stats.TotalFiles, jsonString(utils.FormatBytes(stats.TotalSize)), stats.TextFiles, stats.BinaryFiles)
+`, statsJSON, stats.TotalFiles, jsonString(stats.ManifestDigest))
 
-	if _, err := w.writer.WriteString(statsJSON); err != nil {
+	if _, err := w.writer.WriteString(footer); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// jsonStatistics is the statistics object of the JSON format
+// Field order is the order in the document
+type jsonStatistics struct {
+	TotalFiles     int                          `json:"total_files"`
+	TotalSize      string                       `json:"total_size"`
+	TotalSizeBytes int64                        `json:"total_size_bytes"`
+	TextFiles      int                          `json:"text_files"`
+	BinaryFiles    int                          `json:"binary_files"`
+	TotalLines     int                          `json:"total_lines"`
+	TotalTokens    int                          `json:"total_tokens"`
+	DurationMS     int64                        `json:"duration_ms"`
+	Processing     []string                     `json:"processing"`
+	Languages      map[string]jsonLanguageStats `json:"languages"`
+	Skipped        []jsonSkippedFile            `json:"skipped_files"`
+	Errors         map[string][]jsonScanError   `json:"errors"`
+}
+
+type jsonLanguageStats struct {
+	Name      string `json:"name"`
+	Files     int    `json:"files"`
+	Lines     int    `json:"lines"`
+	Tokens    int    `json:"tokens"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+type jsonSkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+type jsonScanError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	Skipped bool   `json:"skipped"`
+}
+
+// newJSONStatistics collects the footer totals shared by JSON and JSONL
+// Slices and maps are never nil, so empty sections read as [] and {}
+func newJSONStatistics(stats *scanner.StreamingStats, opts types.OutputOptions) jsonStatistics {
+	result := jsonStatistics{
+		TotalFiles:     stats.TotalFiles,
+		TotalSize:      utils.FormatBytes(stats.TotalSize),
+		TotalSizeBytes: stats.TotalSize,
+		TextFiles:      stats.TextFiles,
+		BinaryFiles:    stats.BinaryFiles,
+		TotalLines:     stats.TotalLines,
+		TotalTokens:    stats.TotalTokens,
+		DurationMS:     stats.Duration.Milliseconds(),
+		Processing:     append([]string{}, processingOptions(opts)...),
+		Languages:      make(map[string]jsonLanguageStats),
+		Skipped:        []jsonSkippedFile{},
+		Errors:         make(map[string][]jsonScanError),
+	}
+
+	for _, lang := range sortedLanguages(stats) {
+		result.Languages[lang.ID] = jsonLanguageStats{
+			Name:      lang.Name,
+			Files:     lang.Files,
+			Lines:     lang.Lines,
+			Tokens:    lang.Tokens,
+			SizeBytes: lang.Size,
+		}
+	}
+	for _, skipped := range stats.Skipped {
+		result.Skipped = append(result.Skipped, jsonSkippedFile{Path: skipped.Path, Reason: skipped.Reason})
+	}
+	for _, group := range errorsByPhase(stats.Errors) {
+		for _, scanErr := range group.Errors {
+			result.Errors[group.Phase] = append(result.Errors[group.Phase], jsonScanError{
+				Path:    scanErr.Path,
+				Message: errorMessage(scanErr),
+				Skipped: scanErr.Skipped,
+			})
+		}
+	}
+	return result
+}
+
 func (w *StreamingJSONWriter) Close() error {
 	return w.writer.Flush()
 }
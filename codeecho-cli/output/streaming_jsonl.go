@@ -0,0 +1,239 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+)
+
+// JSONL record shapes (--jsonl-shape)
+const (
+	// One record per file plus leading metadata and trailing stats records
+	JSONLShapeFile = "file"
+	// OpenAI chat fine-tuning records: {"messages": [...]} per file only
+	JSONLShapeOpenAIChat = "openai-chat"
+)
+
+// ValidJSONLShape reports whether shape is a supported --jsonl-shape value
+func ValidJSONLShape(shape string) bool {
+	switch shape {
+	case "", JSONLShapeFile, JSONLShapeOpenAIChat:
+		return true
+	}
+	return false
+}
+
+// StreamingJSONLWriter writes one compact JSON record per line
+// Why: Retrieval and fine-tuning pipelines ingest records line by line,
+// which a single pretty-printed document doesn't allow
+type StreamingJSONLWriter struct {
+	writer   *bufio.Writer
+	encoder  *json.Encoder
+	opts     types.OutputOptions
+	repoName string
+
+	// The metadata record collects header, git and tree before it is written
+	metadata        jsonlMetadataRecord
+	metadataWritten bool
+}
+
+type jsonlMetadataRecord struct {
+	Type          string               `json:"type"`
+	SchemaVersion string               `json:"schema_version"`
+	RepoPath      string               `json:"repo_path"`
+	ScanTime      string               `json:"scan_time"`
+	ProcessedBy   string               `json:"processed_by"`
+	Git           *scanner.GitMetadata `json:"git,omitempty"`
+	DirectoryTree string               `json:"directory_tree,omitempty"`
+}
+
+type jsonlFileRecord struct {
+	Type     string `json:"type"`
+	Path     string `json:"path"`
+	Language string `json:"language,omitempty"`
+	Size     int64  `json:"size"`
+	Lines    int    `json:"lines,omitempty"`
+	Tokens   int    `json:"tokens"`
+	IsText   bool   `json:"is_text"`
+	Category string `json:"category,omitempty"`
+	Sampled  bool   `json:"sampled,omitempty"`
+	// Notebook JSON flattened to source; the original cannot be restored
+	Flattened bool   `json:"flattened,omitempty"`
+	SHA256    string `json:"sha256,omitempty"`
+	// Of content as packed; the manifest is computed from both hashes
+	ContentSHA256 string `json:"content_sha256,omitempty"`
+	MimeType      string `json:"mime_type,omitempty"`
+	Content       string `json:"content,omitempty"`
+	Extension     string `json:"extension,omitempty"`
+}
+
+type jsonlStatsRecord struct {
+	Type           string                       `json:"type"`
+	TotalFiles     int                          `json:"total_files"`
+	TotalSize      int64                        `json:"total_size"`
+	TextFiles      int                          `json:"text_files"`
+	BinaryFiles    int                          `json:"binary_files"`
+	TotalLines     int                          `json:"total_lines"`
+	TotalTokens    int                          `json:"total_tokens"`
+	DurationMS     int64                        `json:"duration_ms"`
+	Processing     []string                     `json:"processing"`
+	LanguageCounts map[string]int               `json:"language_counts"`
+	Languages      map[string]jsonLanguageStats `json:"languages"`
+	Skipped        []jsonSkippedFile            `json:"skipped_files"`
+	Errors         map[string][]jsonScanError   `json:"errors"`
+	ManifestDigest string                       `json:"manifest_digest"`
+}
+
+type jsonlChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type jsonlChatRecord struct {
+	Messages []jsonlChatMessage `json:"messages"`
+}
+
+func NewStreamingJSONLWriter(w io.Writer, opts types.OutputOptions) *StreamingJSONLWriter {
+	writer := bufio.NewWriterSize(w, 65536)
+	encoder := json.NewEncoder(writer)
+	// Code is full of <, > and &; escaping them only inflates the records
+	encoder.SetEscapeHTML(false)
+
+	return &StreamingJSONLWriter{
+		writer:  writer,
+		encoder: encoder,
+		opts:    opts,
+	}
+}
+
+func (w *StreamingJSONLWriter) WriteHeader(repoPath string, scanTime string) error {
+	w.repoName = filepath.Base(repoPath)
+	w.metadata = jsonlMetadataRecord{
+		Type:          "metadata",
+		SchemaVersion: SchemaVersion,
+		RepoPath:      repoPath,
+		ScanTime:      scanTime,
+		ProcessedBy:   "CodeEcho CLI",
+	}
+	return nil
+}
+
+func (w *StreamingJSONLWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
+	w.metadata.Git = git
+	return nil
+}
+
+func (w *StreamingJSONLWriter) WriteTree(tree *scanner.Tree) error {
+	if !w.opts.IncludeDirectoryTree || len(tree.Files) == 0 {
+		return nil
+	}
+	w.metadata.DirectoryTree = RenderTree(tree, w.opts)
+	return nil
+}
+
+// flushMetadata writes the metadata record before the first file or stats record
+func (w *StreamingJSONLWriter) flushMetadata() error {
+	if w.metadataWritten {
+		return nil
+	}
+	w.metadataWritten = true
+
+	if w.opts.JSONLShape == JSONLShapeOpenAIChat {
+		// Fine-tuning files may only contain message records
+		return nil
+	}
+	return w.encoder.Encode(w.metadata)
+}
+
+func (w *StreamingJSONLWriter) WriteFile(file *scanner.FileInfo) error {
+	if err := w.flushMetadata(); err != nil {
+		return err
+	}
+
+	if w.opts.JSONLShape == JSONLShapeOpenAIChat {
+		return w.writeChatRecord(file)
+	}
+
+	record := jsonlFileRecord{
+		Type:          "file",
+		Path:          file.RelativePath,
+		Language:      file.Language,
+		Size:          file.Size,
+		Lines:         file.LineCount,
+		Tokens:        file.TokenCount,
+		IsText:        file.IsText,
+		Sampled:       file.Sampled,
+		Flattened:     file.Flattened,
+		SHA256:        file.SHA256,
+		ContentSHA256: file.ContentSHA256,
+		MimeType:      file.MimeType,
+		Extension:     file.Extension,
+	}
+	if file.Category != scanner.CategorySource {
+		record.Category = file.Category
+	}
+	if w.opts.IncludeContent && file.IsText {
+		record.Content = file.Content
+	}
+
+	return w.encoder.Encode(record)
+}
+
+// writeChatRecord shapes a file as a user/assistant exchange
+// Binary and empty files carry nothing to learn from, so they are skipped
+func (w *StreamingJSONLWriter) writeChatRecord(file *scanner.FileInfo) error {
+	if !file.IsText || file.Content == "" {
+		return nil
+	}
+
+	prompt := fmt.Sprintf("Show the contents of %s", file.RelativePath)
+	if file.Language != "" {
+		prompt += fmt.Sprintf(" (%s)", scanner.LanguageDisplayName(file.Language))
+	}
+
+	record := jsonlChatRecord{
+		Messages: []jsonlChatMessage{
+			{Role: "system", Content: fmt.Sprintf("You are an assistant with full knowledge of the %s repository.", w.repoName)},
+			{Role: "user", Content: prompt},
+			{Role: "assistant", Content: file.Content},
+		},
+	}
+	return w.encoder.Encode(record)
+}
+
+func (w *StreamingJSONLWriter) WriteFooter(stats *scanner.StreamingStats) error {
+	if err := w.flushMetadata(); err != nil {
+		return err
+	}
+
+	if w.opts.JSONLShape == JSONLShapeOpenAIChat {
+		return nil
+	}
+
+	totals := newJSONStatistics(stats, w.opts)
+	return w.encoder.Encode(jsonlStatsRecord{
+		Type:           "stats",
+		TotalFiles:     stats.TotalFiles,
+		TotalSize:      stats.TotalSize,
+		TextFiles:      stats.TextFiles,
+		BinaryFiles:    stats.BinaryFiles,
+		TotalLines:     stats.TotalLines,
+		TotalTokens:    stats.TotalTokens,
+		DurationMS:     totals.DurationMS,
+		Processing:     totals.Processing,
+		LanguageCounts: stats.LanguageCounts,
+		Languages:      totals.Languages,
+		Skipped:        totals.Skipped,
+		Errors:         totals.Errors,
+		ManifestDigest: stats.ManifestDigest,
+	})
+}
+
+func (w *StreamingJSONLWriter) Close() error {
+	return w.writer.Flush()
+}
@@ -12,18 +12,15 @@ import (
 )
 
 type StreamingMarkdownWriter struct {
-	writer *bufio.Writer
-	opts   types.OutputOptions
-	stats  *scanner.StreamingStats
+	writer      *bufio.Writer
+	opts        types.OutputOptions
+	filesOpened bool // "## Files" heading written
 }
 
 func NewStreamingMarkdownWriter(w io.Writer, opts types.OutputOptions) *StreamingMarkdownWriter {
 	return &StreamingMarkdownWriter{
 		writer: bufio.NewWriterSize(w, 65536),
 		opts:   opts,
-		stats: &scanner.StreamingStats{
-			LanguageCounts: make(map[string]int),
-		},
 	}
 }
 
@@ -32,8 +29,9 @@ func (w *StreamingMarkdownWriter) WriteHeader(repoPath string, scanTime string)
 
 **Repository:** %s
 **Scan Time:** %s
+**Schema Version:** %s
 
-`, repoPath, scanTime)
+`, repoPath, scanTime, SchemaVersion)
 
 	if _, err := w.writer.WriteString(header); err != nil {
 		return err
@@ -44,10 +42,6 @@ func (w *StreamingMarkdownWriter) WriteHeader(repoPath string, scanTime string)
 
 func (w *StreamingMarkdownWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
 	if git == nil {
-		// Continue to files section
-		if _, err := w.writer.WriteString("## Files\n\n"); err != nil {
-			return err
-		}
 		return nil
 	}
 
@@ -86,74 +80,111 @@ func (w *StreamingMarkdownWriter) WriteGitMetadata(git *scanner.GitMetadata) err
 		}
 	}
 
-	if _, err := w.writer.WriteString("\n## Files\n\n"); err != nil {
+	if _, err := w.writer.WriteString("\n"); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (w *StreamingMarkdownWriter) WriteTree(paths []string) error {
-	if !w.opts.IncludeDirectoryTree || len(paths) == 0 {
+func (w *StreamingMarkdownWriter) WriteTree(tree *scanner.Tree) error {
+	if !w.opts.IncludeDirectoryTree || len(tree.Files) == 0 {
 		return nil
 	}
 
-	// Convert paths to FileInfo structs (minimal data needed for tree)
-	fileInfos := make([]scanner.FileInfo, len(paths))
-	for i, path := range paths {
-		fileInfos[i] = scanner.FileInfo{RelativePath: path}
-	}
-
-	tree := GenerateDirectoryTree(fileInfos)
+	rendered := RenderTree(tree, w.opts)
+	fence := markdownFence(rendered)
 
 	// Write tree section
 	if _, err := w.writer.WriteString("## Directory Structure\n\n"); err != nil {
 		return err
 	}
-	if _, err := w.writer.WriteString("```\n"); err != nil {
+	if _, err := w.writer.WriteString(fence + "\n" + rendered + fence + "\n\n"); err != nil {
 		return err
 	}
-	if _, err := w.writer.WriteString(tree); err != nil {
-		return err
+
+	// Table of contents
+	// Why: Files are streamed, so the tree's path list is the only view of
+	// every file before the first one is written. Files skipped later
+	// (generated, vendored, unreadable) keep a dead link
+	var toc strings.Builder
+	toc.WriteString("## Table of Contents\n\n")
+	for _, path := range tree.Paths() {
+		toc.WriteString(fmt.Sprintf("- [%s](#%s)\n", escapeMarkdown(path), fileAnchor(path)))
 	}
-	if _, err := w.writer.WriteString("```\n\n"); err != nil {
+	toc.WriteString("\n")
+	if _, err := w.writer.WriteString(toc.String()); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (w *StreamingMarkdownWriter) WriteFile(file *scanner.FileInfo) error {
-	// Update stats
-	w.stats.TotalFiles++
-	w.stats.TotalSize += file.Size
-
-	if file.IsText {
-		w.stats.TextFiles++
-	} else {
-		w.stats.BinaryFiles++
+// openFiles writes the "## Files" heading once
+// Why: It follows the tree and table of contents, but WriteTree isn't
+// called when the tree is disabled
+func (w *StreamingMarkdownWriter) openFiles() error {
+	if w.filesOpened {
+		return nil
 	}
+	w.filesOpened = true
+	_, err := w.writer.WriteString("## Files\n\n")
+	return err
+}
 
-	if file.Language != "" {
-		w.stats.LanguageCounts[file.Language]++
+func (w *StreamingMarkdownWriter) WriteFile(file *scanner.FileInfo) error {
+	if err := w.openFiles(); err != nil {
+		return err
 	}
 
-	// File header
-	if _, err := w.writer.WriteString(fmt.Sprintf("### %s\n\n", file.RelativePath)); err != nil {
+	// File header, after an anchor the table of contents links to
+	// The blank line ends the HTML block so the heading still renders
+	if _, err := w.writer.WriteString(fmt.Sprintf("<a id=\"%s\"></a>\n\n### %s\n\n", fileAnchor(file.RelativePath), file.RelativePath)); err != nil {
 		return err
 	}
 
 	// Metadata
 	metadata := fmt.Sprintf("**Size:** %s", file.SizeFormatted)
 	if file.Language != "" {
-		metadata += fmt.Sprintf(" | **Language:** %s", file.Language)
+		metadata += fmt.Sprintf(" | **Language:** %s", scanner.LanguageDisplayName(file.Language))
+		if file.LanguageConfidence > 0 && file.LanguageConfidence < 0.9 {
+			// Flag guesses so readers know the label may be wrong
+			metadata += fmt.Sprintf(" (%s, %.0f%% confidence)", file.DetectionMethod, file.LanguageConfidence*100)
+		}
 	}
-	if file.LineCount > 0 {
+	if file.Sampled {
+		metadata += fmt.Sprintf(" | **Lines:** %d (sampled from %d)", file.LineCount, file.OriginalLineCount)
+	} else if file.LineCount > 0 {
 		metadata += fmt.Sprintf(" | **Lines:** %d", file.LineCount)
 	}
+	if file.Flattened {
+		metadata += " | **Notebook:** flattened"
+	}
+	if width, height := textImageSize(file); width > 0 {
+		metadata += fmt.Sprintf(" | **Dimensions:** %dx%d", width, height)
+	}
 	if file.Extension != "" {
 		metadata += fmt.Sprintf(" | **Extension:** %s", file.Extension)
 	}
+	if file.Category != "" && file.Category != scanner.CategorySource {
+		metadata += fmt.Sprintf(" | **Category:** %s", file.Category)
+		if file.CategoryReason != "" {
+			metadata += fmt.Sprintf(" (%s)", file.CategoryReason)
+		}
+	}
+	if file.Encoding != "" && file.Encoding != scanner.EncodingUTF8 {
+		metadata += fmt.Sprintf(" | **Encoding:** %s", file.Encoding)
+	}
+	if file.LineEnding != "" && file.LineEnding != scanner.LineEndingLF {
+		metadata += fmt.Sprintf(" | **Line Endings:** %s", file.LineEnding)
+	}
+	if file.IsText && file.SHA256 != "" {
+		// Binary files list their hash with the binary details below
+		metadata += fmt.Sprintf(" | **SHA-256:** `%s`", file.SHA256)
+	}
+	if file.ContentSHA256 != "" {
+		metadata += fmt.Sprintf(" | **Content SHA-256:** `%s`", file.ContentSHA256)
+	}
 	metadata += fmt.Sprintf(" | **Modified:** %s", file.ModTimeFormatted)
 	metadata += fmt.Sprintf(" | **Text File:** %t\n\n", file.IsText)
 
@@ -163,7 +194,12 @@ func (w *StreamingMarkdownWriter) WriteFile(file *scanner.FileInfo) error {
 
 	// Content
 	if w.opts.IncludeContent && file.Content != "" && file.IsText {
-		codeBlock := fmt.Sprintf("```%s\n%s\n```\n\n", strings.ToLower(file.Language), file.Content)
+		content := file.Content
+		if w.opts.ShowLineNumbers {
+			content = addLineNumbers(content)
+		}
+		fence := markdownFence(content)
+		codeBlock := fmt.Sprintf("%s%s\n%s\n%s\n\n", fence, scanner.LanguageFenceTag(file.Language), content, fence)
 		if _, err := w.writer.WriteString(codeBlock); err != nil {
 			return err
 		}
@@ -171,6 +207,9 @@ func (w *StreamingMarkdownWriter) WriteFile(file *scanner.FileInfo) error {
 		if _, err := w.writer.WriteString("*Binary file - content not displayed*\n\n"); err != nil {
 			return err
 		}
+		if _, err := w.writer.WriteString(describeBinary(file)); err != nil {
+			return err
+		}
 	} else {
 		if _, err := w.writer.WriteString("*Content not included*\n\n"); err != nil {
 			return err
@@ -186,19 +225,63 @@ func (w *StreamingMarkdownWriter) WriteFile(file *scanner.FileInfo) error {
 }
 
 func (w *StreamingMarkdownWriter) WriteFooter(stats *scanner.StreamingStats) error {
-	footer := fmt.Sprintf(`## Scan Statistics
+	// Pack readers find the statistics after the heading, even with no files
+	if err := w.openFiles(); err != nil {
+		return err
+	}
+
+	processing := "none"
+	if options := processingOptions(w.opts); len(options) > 0 {
+		processing = strings.Join(options, ", ")
+	}
+
+	var footer strings.Builder
+	footer.WriteString(fmt.Sprintf(`## Scan Statistics
 
 - **Total Files:** %d
 - **Total Size:** %s
 - **Text Files:** %d
 - **Binary Files:** %d
+- **Total Lines:** %d
+- **Total Tokens:** ~%d
+- **Duration:** %s
+- **Processing:** %s
+- **Skipped Files:** %d
+- **Errors:** %d
+- **Manifest:** %d files, `+"`%s`"+`
+`, stats.TotalFiles, utils.FormatBytes(stats.TotalSize), stats.TextFiles, stats.BinaryFiles,
+		stats.TotalLines, stats.TotalTokens, utils.FormatDuration(stats.Duration), processing,
+		len(stats.Skipped), len(stats.Errors), stats.TotalFiles, stats.ManifestDigest))
+
+	// Bold labels rather than headings, so the footer never reads as a file
+	if languages := sortedLanguages(stats); len(languages) > 0 {
+		footer.WriteString("\n**Languages**\n\n| Language | Files | Lines | Tokens | Size |\n| --- | ---: | ---: | ---: | ---: |\n")
+		for _, lang := range languages {
+			footer.WriteString(fmt.Sprintf("| %s | %d | %d | ~%d | %s |\n",
+				lang.Name, lang.Files, lang.Lines, lang.Tokens, utils.FormatBytes(lang.Size)))
+		}
+	}
+
+	if len(stats.Skipped) > 0 {
+		footer.WriteString("\n**Skipped Files**\n\n")
+		for _, skipped := range stats.Skipped {
+			footer.WriteString(fmt.Sprintf("- `%s`: %s\n", skipped.Path, skipped.Reason))
+		}
+	}
 
----
+	if len(stats.Errors) > 0 {
+		footer.WriteString("\n**Errors by Phase**\n\n")
+		for _, group := range errorsByPhase(stats.Errors) {
+			footer.WriteString(fmt.Sprintf("- **%s** (%d)\n", group.Phase, len(group.Errors)))
+			for _, scanErr := range group.Errors {
+				footer.WriteString(fmt.Sprintf("  - `%s`: %s\n", scanErr.Path, describeError(scanErr)))
+			}
+		}
+	}
 
-*Generated by CodeEcho CLI*
-`, stats.TotalFiles, utils.FormatBytes(stats.TotalSize), stats.TextFiles, stats.BinaryFiles)
+	footer.WriteString("\n---\n\n*Generated by CodeEcho CLI*\n")
 
-	if _, err := w.writer.WriteString(footer); err != nil {
+	if _, err := w.writer.WriteString(footer.String()); err != nil {
 		return err
 	}
 
@@ -208,3 +291,68 @@ func (w *StreamingMarkdownWriter) WriteFooter(stats *scanner.StreamingStats) err
 func (w *StreamingMarkdownWriter) Close() error {
 	return w.writer.Flush()
 }
+
+// markdownFence returns a backtick fence longer than any run of backticks
+// in content
+// Why: A file holding ``` itself (Markdown, docs in comments) would close
+// a fixed fence early and turn the rest of the document inside out
+func markdownFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// markdownEscaper backslash-escapes characters Markdown treats as syntax
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`,
+)
+
+// escapeMarkdown makes a path safe as link text
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// describeBinary renders binary file metadata as a Markdown list
+func describeBinary(file *scanner.FileInfo) string {
+	meta := file.Binary
+	if meta == nil || meta.Format == "" {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("- **Type:** %s (%s)\n", meta.Format, file.MimeType))
+	if meta.Width > 0 && meta.Height > 0 {
+		builder.WriteString(fmt.Sprintf("- **Dimensions:** %dx%d\n", meta.Width, meta.Height))
+	}
+	if meta.PageCount > 0 {
+		builder.WriteString(fmt.Sprintf("- **Pages:** %d\n", meta.PageCount))
+	}
+	if meta.Architecture != "" {
+		builder.WriteString(fmt.Sprintf("- **Architecture:** %s\n", meta.Architecture))
+	}
+	if len(meta.Imports) > 0 {
+		builder.WriteString(fmt.Sprintf("- **Imports:** %s\n", strings.Join(meta.Imports, ", ")))
+	}
+	if meta.EntryCount > 0 {
+		builder.WriteString(fmt.Sprintf("- **Entries (%d):**\n", meta.EntryCount))
+		for _, entry := range meta.Entries {
+			builder.WriteString(fmt.Sprintf("  - `%s`\n", entry))
+		}
+		if meta.EntryCount > len(meta.Entries) {
+			builder.WriteString(fmt.Sprintf("  - ... and %d more\n", meta.EntryCount-len(meta.Entries)))
+		}
+	}
+	if file.SHA256 != "" {
+		builder.WriteString(fmt.Sprintf("- **SHA-256:** `%s`\n", file.SHA256))
+	}
+	builder.WriteString("\n")
+
+	return builder.String()
+}
@@ -0,0 +1,123 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+)
+
+// MultiOutput is one writer fed by a MultiStreamingWriter
+type MultiOutput struct {
+	Format string
+	Path   string
+	Writer StreamingWriter
+	Err    error // First error the writer returned; it gets no calls after it
+}
+
+// MultiStreamingWriter fans a single scan out to several writers
+// Why: Every format is written from one walk of the repository, so adding
+// a format does not mean reading every file again
+type MultiStreamingWriter struct {
+	outputs []*MultiOutput
+	strict  bool
+}
+
+// NewMultiStreamingWriter writes to every output
+// A writer that fails is dropped and the others go on, unless strict is set,
+// in which case the first failure is returned
+func NewMultiStreamingWriter(outputs []*MultiOutput, strict bool) *MultiStreamingWriter {
+	return &MultiStreamingWriter{outputs: outputs, strict: strict}
+}
+
+func (m *MultiStreamingWriter) WriteHeader(repoPath string, scanTime string) error {
+	return m.each(func(w StreamingWriter) error {
+		return w.WriteHeader(repoPath, scanTime)
+	})
+}
+
+func (m *MultiStreamingWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
+	return m.each(func(w StreamingWriter) error {
+		return w.WriteGitMetadata(git)
+	})
+}
+
+func (m *MultiStreamingWriter) WriteTree(tree *scanner.Tree) error {
+	return m.each(func(w StreamingWriter) error {
+		return w.WriteTree(tree)
+	})
+}
+
+// WriteFile hands the same file to every writer
+// Writers only read it, so one FileInfo is shared rather than copied
+func (m *MultiStreamingWriter) WriteFile(file *scanner.FileInfo) error {
+	return m.each(func(w StreamingWriter) error {
+		return w.WriteFile(file)
+	})
+}
+
+func (m *MultiStreamingWriter) WriteFooter(stats *scanner.StreamingStats) error {
+	return m.each(func(w StreamingWriter) error {
+		return w.WriteFooter(stats)
+	})
+}
+
+// Close closes every writer, failed ones included, so files are released
+func (m *MultiStreamingWriter) Close() error {
+	for _, out := range m.outputs {
+		err := out.Writer.Close()
+		if err != nil && out.Err == nil {
+			out.Err = fmt.Errorf("%s: %w", out.Format, err)
+		}
+	}
+	if failed := m.Failed(); m.strict && len(failed) > 0 {
+		return failed[0].Err
+	}
+	return m.allFailed()
+}
+
+// Failed returns the outputs that stopped on an error
+func (m *MultiStreamingWriter) Failed() []*MultiOutput {
+	var failed []*MultiOutput
+	for _, out := range m.outputs {
+		if out.Err != nil {
+			failed = append(failed, out)
+		}
+	}
+	return failed
+}
+
+// Succeeded returns the outputs that were written completely
+func (m *MultiStreamingWriter) Succeeded() []*MultiOutput {
+	var succeeded []*MultiOutput
+	for _, out := range m.outputs {
+		if out.Err == nil {
+			succeeded = append(succeeded, out)
+		}
+	}
+	return succeeded
+}
+
+// each calls fn for every writer that has not failed yet
+func (m *MultiStreamingWriter) each(fn func(StreamingWriter) error) error {
+	for _, out := range m.outputs {
+		if out.Err != nil {
+			continue
+		}
+		if err := fn(out.Writer); err != nil {
+			out.Err = fmt.Errorf("%s: %w", out.Format, err)
+			if m.strict {
+				return out.Err
+			}
+		}
+	}
+	return m.allFailed()
+}
+
+// allFailed returns the first error once no writer is left
+// Why: Scanning on with nothing to write to only wastes time
+func (m *MultiStreamingWriter) allFailed() error {
+	if len(m.outputs) == 0 || len(m.Failed()) < len(m.outputs) {
+		return nil
+	}
+	return m.outputs[0].Err
+}
@@ -0,0 +1,325 @@
+package output
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+
+	// Pure-Go driver: the binary still builds with CGO_ENABLED=0
+	_ "modernc.org/sqlite"
+)
+
+// Files per transaction
+// Why: One transaction per file makes SQLite fsync thousands of times;
+// one for the whole scan holds every row in the journal
+const sqliteBatchSize = 500
+
+const sqliteSchema = `
+CREATE TABLE metadata (
+	key   TEXT PRIMARY KEY,
+	value TEXT
+);
+CREATE TABLE git (
+	branch       TEXT,
+	commit_hash  TEXT,
+	author       TEXT,
+	commit_date  TEXT,
+	commit_count INTEGER
+);
+CREATE TABLE files (
+	id              INTEGER PRIMARY KEY,
+	path            TEXT NOT NULL UNIQUE,
+	language        TEXT,
+	extension       TEXT,
+	size            INTEGER NOT NULL,
+	lines           INTEGER,
+	tokens          INTEGER,
+	is_text         INTEGER NOT NULL,
+	category        TEXT,
+	category_reason TEXT,
+	encoding        TEXT,
+	line_endings    TEXT,
+	mime_type       TEXT,
+	sha256          TEXT,
+	content_sha256  TEXT,
+	sampled         INTEGER NOT NULL DEFAULT 0,
+	flattened       INTEGER NOT NULL DEFAULT 0,
+	modified        TEXT,
+	content         TEXT
+);
+CREATE INDEX files_language ON files(language);
+CREATE VIRTUAL TABLE files_fts USING fts5(path, content, content='files', content_rowid='id');
+CREATE TABLE stats (
+	total_files     INTEGER,
+	total_size      INTEGER,
+	text_files      INTEGER,
+	binary_files    INTEGER,
+	total_lines     INTEGER,
+	total_tokens    INTEGER,
+	error_count     INTEGER,
+	skipped_count   INTEGER,
+	duration_ms     INTEGER,
+	processing      TEXT,
+	manifest_digest TEXT
+);
+CREATE TABLE languages (
+	language TEXT PRIMARY KEY,
+	name     TEXT,
+	files    INTEGER,
+	lines    INTEGER,
+	tokens   INTEGER,
+	size     INTEGER
+);
+CREATE TABLE skipped (
+	path   TEXT,
+	reason TEXT
+);
+CREATE TABLE errors (
+	path    TEXT,
+	phase   TEXT,
+	message TEXT,
+	skipped INTEGER
+);
+`
+
+const sqliteSymbolsSchema = `
+CREATE TABLE symbols (
+	file_id INTEGER NOT NULL REFERENCES files(id),
+	name    TEXT NOT NULL,
+	line    INTEGER NOT NULL
+);
+CREATE INDEX symbols_name ON symbols(name);
+`
+
+// StreamingSQLiteWriter writes the scan into a SQLite database
+// Why: A snapshot that can be queried with SQL (and searched with FTS5)
+// is easier for tools and analysts than parsing a pack
+type StreamingSQLiteWriter struct {
+	out    io.Writer
+	path   string // Database file
+	isTemp bool   // path is a temp file copied to out on Close
+	db     *sql.DB
+
+	tx      *sql.Tx
+	pending int // Files written in the current transaction
+
+	opts types.OutputOptions
+}
+
+// NewStreamingSQLiteWriter opens the database behind w
+// SQLite needs a real file: when w is one it is used directly, otherwise
+// the database is built in a temp file and copied to w on Close
+func NewStreamingSQLiteWriter(w io.Writer, opts types.OutputOptions) (*StreamingSQLiteWriter, error) {
+	writer := &StreamingSQLiteWriter{out: w, opts: opts}
+
+	if file, ok := w.(*os.File); ok && file.Name() != "" && file != os.Stdout {
+		writer.path = file.Name()
+	} else {
+		temp, err := os.CreateTemp("", "codeecho-*.db")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp database: %w", err)
+		}
+		temp.Close()
+		writer.path = temp.Name()
+		writer.isTemp = true
+	}
+
+	db, err := sql.Open("sqlite", writer.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// One connection: the schema and every batch share a session
+	db.SetMaxOpenConns(1)
+	writer.db = db
+
+	// The database is written once from scratch, so crash safety only costs time
+	schema := "PRAGMA journal_mode = OFF;\nPRAGMA synchronous = OFF;\n" + sqliteSchema
+	if opts.SQLiteSymbols {
+		schema += sqliteSymbolsSchema
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+
+	return writer, nil
+}
+
+func (w *StreamingSQLiteWriter) WriteHeader(repoPath string, scanTime string) error {
+	return w.setMetadata(map[string]string{
+		"repo_path":      repoPath,
+		"scan_time":      scanTime,
+		"processed_by":   "CodeEcho CLI",
+		"schema_version": SchemaVersion,
+	})
+}
+
+func (w *StreamingSQLiteWriter) setMetadata(values map[string]string) error {
+	for key, value := range values {
+		if _, err := w.db.Exec(`INSERT OR REPLACE INTO metadata (key, value) VALUES (?, ?)`, key, value); err != nil {
+			return fmt.Errorf("failed to write metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *StreamingSQLiteWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
+	if git == nil {
+		return nil
+	}
+	_, err := w.db.Exec(`INSERT INTO git (branch, commit_hash, author, commit_date, commit_count) VALUES (?, ?, ?, ?, ?)`,
+		git.Branch, git.CommitHash, git.Author, git.CommitDate, git.CommitCount)
+	if err != nil {
+		return fmt.Errorf("failed to write git metadata: %w", err)
+	}
+	return nil
+}
+
+func (w *StreamingSQLiteWriter) WriteTree(tree *scanner.Tree) error {
+	if !w.opts.IncludeDirectoryTree || len(tree.Files) == 0 {
+		return nil
+	}
+	return w.setMetadata(map[string]string{"directory_tree": RenderTree(tree, w.opts)})
+}
+
+// begin starts a transaction if none is open
+func (w *StreamingSQLiteWriter) begin() error {
+	if w.tx != nil {
+		return nil
+	}
+	tx, err := w.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	w.tx = tx
+	w.pending = 0
+	return nil
+}
+
+// commit ends the open transaction, if any
+func (w *StreamingSQLiteWriter) commit() error {
+	if w.tx == nil {
+		return nil
+	}
+	err := w.tx.Commit()
+	w.tx = nil
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (w *StreamingSQLiteWriter) WriteFile(file *scanner.FileInfo) error {
+	if err := w.begin(); err != nil {
+		return err
+	}
+
+	var content sql.NullString
+	if w.opts.IncludeContent && file.IsText {
+		content = sql.NullString{String: file.Content, Valid: true}
+	}
+
+	result, err := w.tx.Exec(`INSERT INTO files (path, language, extension, size, lines, tokens, is_text, category,
+		category_reason, encoding, line_endings, mime_type, sha256, content_sha256, sampled, flattened, modified, content)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		file.RelativePath, nullString(file.Language), nullString(file.Extension), file.Size, file.LineCount,
+		file.TokenCount, file.IsText, nullString(file.Category), nullString(file.CategoryReason),
+		nullString(file.Encoding), nullString(file.LineEnding), nullString(file.MimeType),
+		nullString(file.SHA256), nullString(file.ContentSHA256), file.Sampled, file.Flattened, file.ModTime, content)
+	if err != nil {
+		return fmt.Errorf("failed to insert %s: %w", file.RelativePath, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// External-content FTS tables are kept in sync by hand
+	if _, err := w.tx.Exec(`INSERT INTO files_fts (rowid, path, content) VALUES (?, ?, ?)`, id, file.RelativePath, content); err != nil {
+		return fmt.Errorf("failed to index %s: %w", file.RelativePath, err)
+	}
+
+	if w.opts.SQLiteSymbols && content.Valid {
+		for _, symbol := range scanner.FindSymbols(file.Content, file.Language) {
+			if _, err := w.tx.Exec(`INSERT INTO symbols (file_id, name, line) VALUES (?, ?, ?)`, id, symbol.Name, symbol.Line); err != nil {
+				return fmt.Errorf("failed to insert symbol %s: %w", symbol.Name, err)
+			}
+		}
+	}
+
+	w.pending++
+	if w.pending >= sqliteBatchSize {
+		return w.commit()
+	}
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (w *StreamingSQLiteWriter) WriteFooter(stats *scanner.StreamingStats) error {
+	if err := w.begin(); err != nil {
+		return err
+	}
+
+	if _, err := w.tx.Exec(`INSERT INTO stats (total_files, total_size, text_files, binary_files, total_lines, total_tokens,
+		error_count, skipped_count, duration_ms, processing, manifest_digest)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, stats.TotalFiles, stats.TotalSize, stats.TextFiles, stats.BinaryFiles,
+		stats.TotalLines, stats.TotalTokens, len(stats.Errors), len(stats.Skipped), stats.Duration.Milliseconds(),
+		strings.Join(processingOptions(w.opts), ", "), stats.ManifestDigest); err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+
+	for _, lang := range sortedLanguages(stats) {
+		if _, err := w.tx.Exec(`INSERT INTO languages (language, name, files, lines, tokens, size) VALUES (?, ?, ?, ?, ?, ?)`,
+			lang.ID, lang.Name, lang.Files, lang.Lines, lang.Tokens, lang.Size); err != nil {
+			return fmt.Errorf("failed to write language stats: %w", err)
+		}
+	}
+
+	for _, skipped := range stats.Skipped {
+		if _, err := w.tx.Exec(`INSERT INTO skipped (path, reason) VALUES (?, ?)`, skipped.Path, skipped.Reason); err != nil {
+			return fmt.Errorf("failed to write skipped files: %w", err)
+		}
+	}
+
+	for _, scanErr := range stats.Errors {
+		if _, err := w.tx.Exec(`INSERT INTO errors (path, phase, message, skipped) VALUES (?, ?, ?, ?)`,
+			scanErr.Path, scanErr.Phase, errorMessage(scanErr), scanErr.Skipped); err != nil {
+			return fmt.Errorf("failed to write errors: %w", err)
+		}
+	}
+
+	return w.commit()
+}
+
+// Close commits outstanding rows and, for a temp database, copies it out
+func (w *StreamingSQLiteWriter) Close() error {
+	commitErr := w.commit()
+	if err := w.db.Close(); err != nil && commitErr == nil {
+		commitErr = err
+	}
+	if !w.isTemp {
+		return commitErr
+	}
+
+	defer os.Remove(w.path)
+	if commitErr != nil {
+		return commitErr
+	}
+
+	db, err := os.Open(w.path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = io.Copy(w.out, db)
+	return err
+}
@@ -0,0 +1,187 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
+)
+
+// Template blocks, declared with {{define "header"}} ... {{end}}
+// Only "file" is required
+const (
+	templateHeader = "header"
+	templateFile   = "file"
+	templateFooter = "footer"
+)
+
+// TemplateData is the value every template block is executed with
+// File and Index are set for the file block, Stats for the footer
+type TemplateData struct {
+	RepoPath string
+	RepoName string
+	ScanTime string
+	Git      *scanner.GitMetadata // nil outside a git repository
+	Tree     string               // empty unless --include-tree
+	Options  types.OutputOptions
+
+	SchemaVersion string // Version of the layouts with a published schema
+
+	File  *scanner.FileInfo
+	Index int // 1-based position of File in the output
+
+	Stats *scanner.StreamingStats
+}
+
+// StreamingTemplateWriter renders a user's text/template file by file
+// Why: Teams want their own pack layout (a company header, a different
+// separator) without a new writer for each one
+type StreamingTemplateWriter struct {
+	writer *bufio.Writer
+	tmpl   *template.Template
+	opts   types.OutputOptions
+	data   TemplateData
+
+	// The header block runs once git metadata and the tree are known
+	headerWritten bool
+}
+
+// templateFuncs are the helpers available in templates
+var templateFuncs = template.FuncMap{
+	// escape entity-escapes text for XML or HTML layouts
+	"escape": escapeXML,
+	// indent prefixes every non-empty line with n spaces
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		lines := strings.Split(s, "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = pad + line
+			}
+		}
+		return strings.Join(lines, "\n")
+	},
+	// numberLines prefixes lines with their number, as --line-numbers does
+	"numberLines": addLineNumbers,
+	// truncate keeps the first n characters, marking the cut with "..."
+	"truncate": func(n int, s string) string {
+		runes := []rune(s)
+		if n < 0 || len(runes) <= n {
+			return s
+		}
+		return string(runes[:n]) + "..."
+	},
+	// fence returns a code fence that content can't close early
+	"fence": markdownFence,
+	// fenceTag turns a language ID into a Markdown fence info string
+	"fenceTag": scanner.LanguageFenceTag,
+	// language turns a language ID into its display name
+	"language":    scanner.LanguageDisplayName,
+	"formatBytes": utils.FormatBytes,
+	"upper":       strings.ToUpper,
+	"lower":       strings.ToLower,
+	"trim":        strings.TrimSpace,
+}
+
+// NewStreamingTemplateWriter parses the template at opts.Template
+func NewStreamingTemplateWriter(w io.Writer, opts types.OutputOptions) (*StreamingTemplateWriter, error) {
+	if opts.Template == "" {
+		return nil, fmt.Errorf("template format requires --template")
+	}
+
+	source, err := os.ReadFile(opts.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(opts.Template)).Funcs(templateFuncs).Parse(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	if tmpl.Lookup(templateFile) == nil {
+		return nil, fmt.Errorf("template %s must define a %q block: {{define %q}}...{{end}}", opts.Template, templateFile, templateFile)
+	}
+
+	return &StreamingTemplateWriter{
+		writer: bufio.NewWriterSize(w, 65536),
+		tmpl:   tmpl,
+		opts:   opts,
+		data:   TemplateData{Options: opts, SchemaVersion: SchemaVersion},
+	}, nil
+}
+
+func (w *StreamingTemplateWriter) WriteHeader(repoPath string, scanTime string) error {
+	w.data.RepoPath = repoPath
+	w.data.RepoName = filepath.Base(repoPath)
+	w.data.ScanTime = scanTime
+	return nil
+}
+
+func (w *StreamingTemplateWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
+	w.data.Git = git
+	return nil
+}
+
+func (w *StreamingTemplateWriter) WriteTree(tree *scanner.Tree) error {
+	if !w.opts.IncludeDirectoryTree || len(tree.Files) == 0 {
+		return nil
+	}
+	w.data.Tree = RenderTree(tree, w.opts)
+	return nil
+}
+
+// execute runs a block if the template defines it
+func (w *StreamingTemplateWriter) execute(name string) error {
+	if w.tmpl.Lookup(name) == nil {
+		return nil
+	}
+	if err := w.tmpl.ExecuteTemplate(w.writer, name, w.data); err != nil {
+		return fmt.Errorf("template %s block: %w", name, err)
+	}
+	return nil
+}
+
+func (w *StreamingTemplateWriter) writeHeaderBlock() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+	return w.execute(templateHeader)
+}
+
+func (w *StreamingTemplateWriter) WriteFile(file *scanner.FileInfo) error {
+	if err := w.writeHeaderBlock(); err != nil {
+		return err
+	}
+
+	w.data.Index++
+	w.data.File = file
+	if !w.opts.IncludeContent {
+		// Templates print .File.Content unconditionally; honour --no-content here
+		copied := *file
+		copied.Content = ""
+		w.data.File = &copied
+	}
+	err := w.execute(templateFile)
+	w.data.File = nil
+	return err
+}
+
+func (w *StreamingTemplateWriter) WriteFooter(stats *scanner.StreamingStats) error {
+	if err := w.writeHeaderBlock(); err != nil {
+		return err
+	}
+	w.data.Stats = stats
+	return w.execute(templateFooter)
+}
+
+func (w *StreamingTemplateWriter) Close() error {
+	return w.writer.Flush()
+}
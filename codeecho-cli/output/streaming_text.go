@@ -0,0 +1,101 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+)
+
+// StreamingTextWriter writes plain text with "==== path ====" separators
+// Why: Some tools and chat boxes take plain text only, and markup around
+// the code costs tokens without telling the model anything
+type StreamingTextWriter struct {
+	writer *bufio.Writer
+	opts   types.OutputOptions
+}
+
+func NewStreamingTextWriter(w io.Writer, opts types.OutputOptions) *StreamingTextWriter {
+	return &StreamingTextWriter{
+		writer: bufio.NewWriterSize(w, 65536),
+		opts:   opts,
+	}
+}
+
+func (w *StreamingTextWriter) WriteHeader(repoPath string, scanTime string) error {
+	_, err := w.writer.WriteString(fmt.Sprintf("Repository: %s\nScan time: %s\nSchema version: %s\n", repoPath, scanTime, SchemaVersion))
+	return err
+}
+
+func (w *StreamingTextWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
+	if git != nil {
+		var lines strings.Builder
+		if git.Branch != "" {
+			lines.WriteString(fmt.Sprintf("Branch: %s\n", git.Branch))
+		}
+		if git.CommitHash != "" {
+			lines.WriteString(fmt.Sprintf("Commit: %s\n", git.CommitHash))
+		}
+		if git.Author != "" {
+			lines.WriteString(fmt.Sprintf("Author: %s\n", git.Author))
+		}
+		if git.CommitDate != "" {
+			lines.WriteString(fmt.Sprintf("Commit date: %s\n", git.CommitDate))
+		}
+		if _, err := w.writer.WriteString(lines.String()); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.writer.WriteString("\n")
+	return err
+}
+
+func (w *StreamingTextWriter) WriteTree(tree *scanner.Tree) error {
+	if !w.opts.IncludeDirectoryTree || len(tree.Files) == 0 {
+		return nil
+	}
+
+	_, err := w.writer.WriteString("Directory structure:\n" + RenderTree(tree, w.opts) + "\n")
+	return err
+}
+
+func (w *StreamingTextWriter) WriteFile(file *scanner.FileInfo) error {
+	if _, err := w.writer.WriteString(fmt.Sprintf("==== %s ====\n", file.RelativePath)); err != nil {
+		return err
+	}
+
+	content := ""
+	switch {
+	case !file.IsText:
+		content = "(binary file - content not included)"
+		if file.Binary != nil && file.Binary.Format != "" {
+			content = fmt.Sprintf("(binary file: %s - content not included)", file.Binary.Format)
+		}
+	case !w.opts.IncludeContent:
+		content = "(content not included)"
+	case w.opts.ShowLineNumbers:
+		content = addLineNumbers(file.Content)
+	default:
+		content = file.Content
+	}
+
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	_, err := w.writer.WriteString(content + "\n")
+	return err
+}
+
+func (w *StreamingTextWriter) WriteFooter(stats *scanner.StreamingStats) error {
+	_, err := w.writer.WriteString("==== End of repository ====\n" + statisticsText(stats, w.opts) +
+		fmt.Sprintf("Manifest: %s\n", stats.ManifestDigest))
+	return err
+}
+
+func (w *StreamingTextWriter) Close() error {
+	return w.writer.Flush()
+}
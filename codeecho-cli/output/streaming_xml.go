@@ -13,9 +13,26 @@ import (
 
 // StreamingXMLWriter writes XML output incrementally
 type StreamingXMLWriter struct {
-	writer *bufio.Writer // Buffered writer for performance (batches small writes)
-	opts   types.OutputOptions
-	stats  *scanner.StreamingStats // Track stats as we go
+	writer      *bufio.Writer // Buffered writer for performance (batches small writes)
+	opts        types.OutputOptions
+	filesOpened bool // <files> is opened by the first file or the footer
+}
+
+// XML content modes (--xml-content)
+const (
+	// Entity-escape <, >, & and quotes in file content
+	XMLContentEscaped = "escaped"
+	// Wrap file content in CDATA so code reads as written
+	XMLContentCDATA = "cdata"
+)
+
+// ValidXMLContent reports whether mode is a supported --xml-content value
+func ValidXMLContent(mode string) bool {
+	switch mode {
+	case "", XMLContentEscaped, XMLContentCDATA:
+		return true
+	}
+	return false
 }
 
 // NewStreamingXMLWriter creates a new streaming XML writer
@@ -23,9 +40,6 @@ func NewStreamingXMLWriter(w io.Writer, opts types.OutputOptions) *StreamingXMLW
 	return &StreamingXMLWriter{
 		writer: bufio.NewWriterSize(w, 65536), // 64KB buffer for efficiency
 		opts:   opts,
-		stats: &scanner.StreamingStats{
-			LanguageCounts: make(map[string]int),
-		},
 	}
 }
 
@@ -45,17 +59,7 @@ func (w *StreamingXMLWriter) WriteHeader(repoPath string, scanTime string) error
 		return err
 	}
 
-	var options []string
-	if w.opts.RemoveComments {
-		options = append(options, "comments removed")
-	}
-	if w.opts.RemoveEmptyLines {
-		options = append(options, "empty lines removed")
-	}
-	if w.opts.CompressCode {
-		options = append(options, "code compressed")
-	}
-
+	options := processingOptions(w.opts)
 	if len(options) > 0 {
 		if _, err := w.writer.WriteString(strings.Join(options, ", ")); err != nil {
 			return err
@@ -69,6 +73,12 @@ func (w *StreamingXMLWriter) WriteHeader(repoPath string, scanTime string) error
 		return err
 	}
 
+	// Why: A single root element makes the output a well-formed document
+	// that standard XML parsers accept
+	if _, err := w.writer.WriteString(fmt.Sprintf("<codebase schema_version=\"%s\">\n\n", SchemaVersion)); err != nil {
+		return err
+	}
+
 	// File summary section
 	if w.opts.IncludeSummary {
 		summary := `<file_summary>
@@ -101,7 +111,8 @@ The content is organized as follows:
 
 <notes>
 - Some files may have been excluded based on .gitignore rules and CodeEcho's configuration
-- Binary files are not included in this packed representation
+- Binary files are not included in this packed representation; where possible
+  their type, dimensions, archive entries or linked libraries are described instead
 - Files matching default ignore patterns are excluded
 `
 		if _, err := w.writer.WriteString(summary); err != nil {
@@ -114,6 +125,18 @@ The content is organized as follows:
 			}
 		}
 
+		if w.opts.XMLContent == XMLContentCDATA {
+			if _, err := w.writer.WriteString("- File contents are wrapped in CDATA sections and are not entity-escaped\n"); err != nil {
+				return err
+			}
+		}
+
+		if w.opts.SampleDataFiles {
+			if _, err := w.writer.WriteString("- Large data files are sampled; files marked sampled=\"true\" show only part of their rows\n"); err != nil {
+				return err
+			}
+		}
+
 		if _, err := w.writer.WriteString(fmt.Sprintf("- Generated by CodeEcho CLI on %s\n", scanTime)); err != nil {
 			return err
 		}
@@ -129,7 +152,7 @@ The content is organized as follows:
 	if _, err := w.writer.WriteString(fmt.Sprintf("<repo_path>%s</repo_path>\n", escapeXML(repoPath))); err != nil {
 		return err
 	}
-	if _, err := w.writer.WriteString(fmt.Sprintf("<scan_time>%s</scan_time>\n", scanTime)); err != nil {
+	if _, err := w.writer.WriteString(fmt.Sprintf("<scan_time>%s</scan_time>\n", escapeXML(scanTime))); err != nil {
 		return err
 	}
 
@@ -186,56 +209,43 @@ func (w *StreamingXMLWriter) WriteGitMetadata(git *scanner.GitMetadata) error {
 	return nil
 }
 
-func (w *StreamingXMLWriter) WriteTree(paths []string) error {
-	if !w.opts.IncludeDirectoryTree || len(paths) == 0 {
-		// Start files section even if no tree
-		if _, err := w.writer.WriteString("<files>\nThis section contains the contents of the repository's files.\n\n"); err != nil {
-			return err
-		}
+func (w *StreamingXMLWriter) WriteTree(tree *scanner.Tree) error {
+	if !w.opts.IncludeDirectoryTree || len(tree.Files) == 0 {
 		return nil
 	}
 
-	// Convert paths to FileInfo structs (minimal data)
-	fileInfos := make([]scanner.FileInfo, len(paths))
-	for i, path := range paths {
-		fileInfos[i] = scanner.FileInfo{RelativePath: path}
-	}
-
-	tree := GenerateDirectoryTree(fileInfos)
+	rendered := RenderTree(tree, w.opts)
 
 	if _, err := w.writer.WriteString("<directory_structure>\n"); err != nil {
 		return err
 	}
-	if _, err := w.writer.WriteString(tree); err != nil {
+	if _, err := w.writer.WriteString(escapeXML(rendered)); err != nil {
 		return err
 	}
 	if _, err := w.writer.WriteString("</directory_structure>\n\n"); err != nil {
 		return err
 	}
 
-	// Start files section
-	if _, err := w.writer.WriteString("<files>\nThis section contains the contents of the repository's files.\n\n"); err != nil {
-		return err
-	}
-
 	return nil
 }
 
+// openFiles starts the files section once
+// Why: WriteTree isn't called when the tree is disabled, so the section
+// can't be opened there without leaving </files> unmatched
+func (w *StreamingXMLWriter) openFiles() error {
+	if w.filesOpened {
+		return nil
+	}
+	w.filesOpened = true
+	_, err := w.writer.WriteString("<files>\nThis section contains the contents of the repository's files.\n\n")
+	return err
+}
+
 // WriteFile writes a single file entry
 // This is called once per file - the key to streaming!
 func (w *StreamingXMLWriter) WriteFile(file *scanner.FileInfo) error {
-	// Update statistics as we go
-	w.stats.TotalFiles++
-	w.stats.TotalSize += file.Size
-
-	if file.IsText {
-		w.stats.TextFiles++
-	} else {
-		w.stats.BinaryFiles++
-	}
-
-	if file.Language != "" {
-		w.stats.LanguageCounts[file.Language]++
+	if err := w.openFiles(); err != nil {
+		return err
 	}
 
 	// Write file opening tag with attributes
@@ -244,9 +254,14 @@ func (w *StreamingXMLWriter) WriteFile(file *scanner.FileInfo) error {
 	}
 
 	if file.Language != "" {
-		if _, err := w.writer.WriteString(fmt.Sprintf(` language="%s"`, file.Language)); err != nil {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` language="%s"`, escapeXML(file.Language))); err != nil {
 			return err
 		}
+		if file.DetectionMethod != "" {
+			if _, err := w.writer.WriteString(fmt.Sprintf(` detection="%s" confidence="%.2f"`, escapeXML(file.DetectionMethod), file.LanguageConfidence)); err != nil {
+				return err
+			}
+		}
 	}
 
 	if file.LineCount > 0 {
@@ -255,17 +270,29 @@ func (w *StreamingXMLWriter) WriteFile(file *scanner.FileInfo) error {
 		}
 	}
 
-	if _, err := w.writer.WriteString(fmt.Sprintf(` size="%s"`, file.SizeFormatted)); err != nil {
+	// Sampled data files record how much of the original was kept
+	if file.Sampled {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` original_lines="%d" sampled="true"`, file.OriginalLineCount)); err != nil {
+			return err
+		}
+	}
+	if file.Flattened {
+		if _, err := w.writer.WriteString(` flattened="true"`); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.writer.WriteString(fmt.Sprintf(` size="%s" size_bytes="%d"`, escapeXML(file.SizeFormatted), file.Size)); err != nil {
 		return err
 	}
 
 	if file.Extension != "" {
-		if _, err := w.writer.WriteString(fmt.Sprintf(` extension="%s"`, file.Extension)); err != nil {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` extension="%s"`, escapeXML(file.Extension))); err != nil {
 			return err
 		}
 	}
 
-	if _, err := w.writer.WriteString(fmt.Sprintf(` modified="%s"`, file.ModTimeFormatted)); err != nil {
+	if _, err := w.writer.WriteString(fmt.Sprintf(` modified="%s"`, escapeXML(file.ModTimeFormatted))); err != nil {
 		return err
 	}
 
@@ -273,27 +300,90 @@ func (w *StreamingXMLWriter) WriteFile(file *scanner.FileInfo) error {
 		return err
 	}
 
+	// Only non-source files are labelled, to keep ordinary entries short
+	if file.Category != "" && file.Category != scanner.CategorySource {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` category="%s"`, escapeXML(file.Category))); err != nil {
+			return err
+		}
+		if file.CategoryReason != "" {
+			if _, err := w.writer.WriteString(fmt.Sprintf(` category_reason="%s"`, escapeXML(file.CategoryReason))); err != nil {
+				return err
+			}
+		}
+	}
+
+	if file.Encoding != "" {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` encoding="%s"`, escapeXML(file.Encoding))); err != nil {
+			return err
+		}
+	}
+
+	if file.LineEnding != "" {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` line_endings="%s"`, escapeXML(file.LineEnding))); err != nil {
+			return err
+		}
+	}
+
+	if file.IndentStyle != "" {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` indent_style="%s"`, escapeXML(file.IndentStyle))); err != nil {
+			return err
+		}
+	}
+	if file.IndentSize > 0 {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` indent_size="%d"`, file.IndentSize)); err != nil {
+			return err
+		}
+	}
+
+	if file.MimeType != "" {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` mime="%s"`, escapeXML(file.MimeType))); err != nil {
+			return err
+		}
+	}
+	// Text images (SVG) keep their content, so their size goes on <file>
+	if width, height := textImageSize(file); width > 0 {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` width="%d" height="%d"`, width, height)); err != nil {
+			return err
+		}
+	}
+
+	if file.SHA256 != "" {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` sha256="%s"`, file.SHA256)); err != nil {
+			return err
+		}
+	}
+
+	if file.ContentSHA256 != "" {
+		if _, err := w.writer.WriteString(fmt.Sprintf(` content_sha256="%s"`, file.ContentSHA256)); err != nil {
+			return err
+		}
+	}
+
 	if _, err := w.writer.WriteString(">\n"); err != nil {
 		return err
 	}
 
 	// Write content
 	if w.opts.IncludeContent && file.Content != "" && file.IsText {
+		content := file.Content
 		if w.opts.ShowLineNumbers {
-			numbered := addLineNumbers(file.Content)
-			if _, err := w.writer.WriteString(numbered); err != nil {
-				return err
-			}
+			content = addLineNumbers(content)
+		}
+		if w.opts.XMLContent == XMLContentCDATA {
+			content = cdata(content)
 		} else {
-			escaped := escapeXML(file.Content)
-			if _, err := w.writer.WriteString(escaped); err != nil {
-				return err
-			}
+			content = escapeXML(content)
+		}
+		if _, err := w.writer.WriteString(content); err != nil {
+			return err
 		}
 	} else if !file.IsText {
 		if _, err := w.writer.WriteString("<!-- Binary file - content not included -->"); err != nil {
 			return err
 		}
+		if err := w.writeBinaryMetadata(file.Binary); err != nil {
+			return err
+		}
 	} else {
 		if _, err := w.writer.WriteString("<!-- Content not included -->"); err != nil {
 			return err
@@ -308,27 +398,121 @@ func (w *StreamingXMLWriter) WriteFile(file *scanner.FileInfo) error {
 	return nil
 }
 
+// writeBinaryMetadata describes a binary file in place of its content
+func (w *StreamingXMLWriter) writeBinaryMetadata(meta *scanner.BinaryMetadata) error {
+	if meta == nil || meta.Format == "" {
+		return nil
+	}
+
+	var attrs strings.Builder
+	attrs.WriteString(fmt.Sprintf(` format="%s"`, escapeXML(meta.Format)))
+	if meta.Width > 0 && meta.Height > 0 {
+		attrs.WriteString(fmt.Sprintf(` width="%d" height="%d"`, meta.Width, meta.Height))
+	}
+	if meta.PageCount > 0 {
+		attrs.WriteString(fmt.Sprintf(` pages="%d"`, meta.PageCount))
+	}
+	if meta.EntryCount > 0 {
+		attrs.WriteString(fmt.Sprintf(` entries="%d"`, meta.EntryCount))
+	}
+	if meta.Architecture != "" {
+		attrs.WriteString(fmt.Sprintf(` arch="%s"`, escapeXML(meta.Architecture)))
+	}
+
+	if len(meta.Entries) == 0 && len(meta.Imports) == 0 {
+		_, err := w.writer.WriteString(fmt.Sprintf("\n<binary%s/>", attrs.String()))
+		return err
+	}
+
+	if _, err := w.writer.WriteString(fmt.Sprintf("\n<binary%s>\n", attrs.String())); err != nil {
+		return err
+	}
+	for _, entry := range meta.Entries {
+		if _, err := w.writer.WriteString(fmt.Sprintf("  <entry>%s</entry>\n", escapeXML(entry))); err != nil {
+			return err
+		}
+	}
+	for _, imp := range meta.Imports {
+		if _, err := w.writer.WriteString(fmt.Sprintf("  <import>%s</import>\n", escapeXML(imp))); err != nil {
+			return err
+		}
+	}
+	_, err := w.writer.WriteString("</binary>")
+	return err
+}
+
 // WriteFooter writes closing tags and final statistics
 func (w *StreamingXMLWriter) WriteFooter(stats *scanner.StreamingStats) error {
-	// Close files section
+	// Close files section (opened here if there were no files)
+	if err := w.openFiles(); err != nil {
+		return err
+	}
 	if _, err := w.writer.WriteString("</files>\n\n"); err != nil {
 		return err
 	}
 
-	// Write final statistics section
-	statsXML := fmt.Sprintf(`<scan_statistics>
+	if _, err := w.writer.WriteString(w.statisticsXML(stats)); err != nil {
+		return err
+	}
+
+	if _, err := w.writer.WriteString(fmt.Sprintf("<manifest files=\"%d\" digest=\"%s\"/>\n", stats.TotalFiles, stats.ManifestDigest)); err != nil {
+		return err
+	}
+
+	if _, err := w.writer.WriteString("</codebase>\n"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// statisticsXML renders the final statistics section
+func (w *StreamingXMLWriter) statisticsXML(stats *scanner.StreamingStats) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`<scan_statistics>
 <total_files>%d</total_files>
 <total_size>%s</total_size>
+<total_size_bytes>%d</total_size_bytes>
 <text_files>%d</text_files>
 <binary_files>%d</binary_files>
-</scan_statistics>
-`, stats.TotalFiles, utils.FormatBytes(stats.TotalSize), stats.TextFiles, stats.BinaryFiles)
+<total_lines>%d</total_lines>
+<total_tokens>%d</total_tokens>
+<duration_ms>%d</duration_ms>
+`, stats.TotalFiles, utils.FormatBytes(stats.TotalSize), stats.TotalSize, stats.TextFiles, stats.BinaryFiles,
+		stats.TotalLines, stats.TotalTokens, stats.Duration.Milliseconds()))
 
-	if _, err := w.writer.WriteString(statsXML); err != nil {
-		return err
+	b.WriteString("<processing>\n")
+	for _, option := range processingOptions(w.opts) {
+		b.WriteString(fmt.Sprintf("  <option>%s</option>\n", escapeXML(option)))
 	}
+	b.WriteString("</processing>\n")
 
-	return nil
+	b.WriteString("<languages>\n")
+	for _, lang := range sortedLanguages(stats) {
+		b.WriteString(fmt.Sprintf("  <language name=\"%s\" display_name=\"%s\" files=\"%d\" lines=\"%d\" tokens=\"%d\" size_bytes=\"%d\"/>\n",
+			escapeXML(lang.ID), escapeXML(lang.Name), lang.Files, lang.Lines, lang.Tokens, lang.Size))
+	}
+	b.WriteString("</languages>\n")
+
+	b.WriteString(fmt.Sprintf("<skipped_files count=\"%d\">\n", len(stats.Skipped)))
+	for _, skipped := range stats.Skipped {
+		b.WriteString(fmt.Sprintf("  <skipped path=\"%s\" reason=\"%s\"/>\n", escapeXML(skipped.Path), escapeXML(skipped.Reason)))
+	}
+	b.WriteString("</skipped_files>\n")
+
+	b.WriteString(fmt.Sprintf("<errors count=\"%d\">\n", len(stats.Errors)))
+	for _, group := range errorsByPhase(stats.Errors) {
+		b.WriteString(fmt.Sprintf("  <phase name=\"%s\" count=\"%d\">\n", escapeXML(group.Phase), len(group.Errors)))
+		for _, scanErr := range group.Errors {
+			b.WriteString(fmt.Sprintf("    <error path=\"%s\" skipped=\"%t\">%s</error>\n",
+				escapeXML(scanErr.Path), scanErr.Skipped, escapeXML(errorMessage(scanErr))))
+		}
+		b.WriteString("  </phase>\n")
+	}
+	b.WriteString("</errors>\n")
+
+	b.WriteString("</scan_statistics>\n")
+	return b.String()
 }
 
 // Close flushes the buffer and closes the writer
@@ -336,8 +520,18 @@ func (w *StreamingXMLWriter) Close() error {
 	return w.writer.Flush() // Important: flush buffered data to disk
 }
 
+// textImageSize returns the dimensions of a text file that is an image
+// (SVG), or zeros
+func textImageSize(file *scanner.FileInfo) (int, int) {
+	if !file.IsText || file.Binary == nil || file.Binary.Width <= 0 || file.Binary.Height <= 0 {
+		return 0, 0
+	}
+	return file.Binary.Width, file.Binary.Height
+}
+
 // Helper functions for XML processing
 func escapeXML(s string) string {
+	s = utils.StripInvalidXMLChars(s)
 	s = strings.ReplaceAll(s, "&", "&amp;")
 	s = strings.ReplaceAll(s, "<", "&lt;")
 	s = strings.ReplaceAll(s, ">", "&gt;")
@@ -346,6 +540,14 @@ func escapeXML(s string) string {
 	return s
 }
 
+// cdata wraps s in a CDATA section
+// "]]>" can't appear inside CDATA, so it is split across two sections:
+// "]]" ends the first and ">" starts the next
+func cdata(s string) string {
+	s = utils.StripInvalidXMLChars(s)
+	return "<![CDATA[" + strings.ReplaceAll(s, "]]>", "]]]]><![CDATA[>") + "]]>"
+}
+
 func addLineNumbers(content string) string {
 	lines := strings.Split(content, "\n")
 	var numberedLines []string
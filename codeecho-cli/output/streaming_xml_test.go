@@ -0,0 +1,130 @@
+package output
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+)
+
+// xmlPack is the part of the XML output the round trip checks
+type xmlPack struct {
+	Tree  string `xml:"directory_structure"`
+	Files []struct {
+		Path    string `xml:"path,attr"`
+		Content string `xml:",chardata"`
+	} `xml:"files>file"`
+}
+
+// writeXMLPack writes files as an XML pack and parses it back
+func writeXMLPack(t *testing.T, opts types.OutputOptions, files []scanner.FileInfo) xmlPack {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := NewStreamingXMLWriter(&buf, opts)
+	if err := writer.WriteHeader("/repo", "2024-01-02T03:04:05Z"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteGitMetadata(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteTree(scanner.TreeFromFiles("/repo", files)); err != nil {
+		t.Fatal(err)
+	}
+	for i := range files {
+		if err := writer.WriteFile(&files[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.WriteFooter(scanner.NewStreamingStats()); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var pack xmlPack
+	if err := xml.Unmarshal(buf.Bytes(), &pack); err != nil {
+		t.Fatalf("output is not well-formed XML: %v\n%s", err, buf.String())
+	}
+	return pack
+}
+
+func TestXMLContentRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    string // Content after parsing; same as content when empty
+	}{
+		{name: "cdata terminator", path: "end.go", content: "s := \"]]>\"\nt := \"]]]]>\"\n"},
+		{name: "markup characters", path: "a&b<c>.html", content: "<p class=\"x\">Tom & 'Jerry'</p>\n&amp; stays &amp;\n"},
+		{name: "control characters", path: "ctl.go", content: "// \x1b[31mred\x1b[0m\n\f\x00tab\there\n", want: "// [31mred[0m\ntab\there\n"},
+		{name: "invalid code points", path: "nc.txt", content: "a\ufffeb\uffffc\n", want: "abc\n"},
+		{name: "non-ASCII", path: "ü.txt", content: "héllo 世界 😀\n"},
+		{name: "empty lines", path: "blank.txt", content: "\n\nx\n\n"},
+	}
+
+	for _, mode := range []string{XMLContentEscaped, XMLContentCDATA} {
+		for _, lineNumbers := range []bool{false, true} {
+			opts := types.OutputOptions{
+				IncludeContent:       true,
+				IncludeDirectoryTree: true,
+				ShowLineNumbers:      lineNumbers,
+				XMLContent:           mode,
+			}
+
+			var files []scanner.FileInfo
+			for _, tt := range tests {
+				files = append(files, scanner.FileInfo{
+					Path:         "/repo/" + tt.path,
+					RelativePath: tt.path,
+					Content:      tt.content,
+					IsText:       true,
+				})
+			}
+
+			pack := writeXMLPack(t, opts, files)
+			if len(pack.Files) != len(tests) {
+				t.Fatalf("%s, line numbers %t: got %d files, want %d", mode, lineNumbers, len(pack.Files), len(tests))
+			}
+
+			for i, tt := range tests {
+				want := tt.want
+				if want == "" {
+					want = tt.content
+				}
+				if lineNumbers {
+					want = addLineNumbers(want)
+				}
+				// The writer puts the content on its own lines inside <file>
+				got := strings.TrimSuffix(strings.TrimPrefix(pack.Files[i].Content, "\n"), "\n")
+
+				if pack.Files[i].Path != tt.path {
+					t.Errorf("%s, line numbers %t, %s: path %q, want %q", mode, lineNumbers, tt.name, pack.Files[i].Path, tt.path)
+				}
+				if got != want {
+					t.Errorf("%s, line numbers %t, %s: content %q, want %q", mode, lineNumbers, tt.name, got, want)
+				}
+				if !strings.Contains(pack.Tree, tt.path[strings.LastIndex(tt.path, "/")+1:]) {
+					t.Errorf("%s, line numbers %t, %s: tree does not list the file:\n%s", mode, lineNumbers, tt.name, pack.Tree)
+				}
+			}
+		}
+	}
+}
+
+func TestXMLStructureOnly(t *testing.T) {
+	pack := writeXMLPack(t, types.OutputOptions{IncludeContent: false}, []scanner.FileInfo{
+		{Path: "/repo/a.go", RelativePath: "a.go", Content: "package a ]]> <", IsText: true},
+	})
+	if len(pack.Files) != 1 {
+		t.Fatalf("got %d files, want 1", len(pack.Files))
+	}
+	if got := strings.TrimSpace(pack.Files[0].Content); got != "" {
+		t.Errorf("content written without --content: %q", got)
+	}
+}
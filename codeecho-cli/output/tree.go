@@ -3,53 +3,203 @@ package output
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+	"github.com/NesoHQ/code-echo/codeecho-cli/types"
+	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
 )
 
-func GenerateDirectoryTree(files []scanner.FileInfo) string {
-	if len(files) == 0 {
+// Directory tree styles (--tree-style)
+const (
+	// ├── and └── box-drawing branches
+	TreeStyleUnicode = "unicode"
+	// |-- and `-- branches for terminals and fonts without box drawing
+	TreeStyleASCII = "ascii"
+	// Two spaces per level, no branches
+	TreeStyleIndent = "indent"
+)
+
+// ValidTreeStyle reports whether style is a supported --tree-style value
+func ValidTreeStyle(style string) bool {
+	switch style {
+	case "", TreeStyleUnicode, TreeStyleASCII, TreeStyleIndent:
+		return true
+	}
+	return false
+}
+
+// treeBranches are the prefixes a style draws before an entry
+type treeBranches struct {
+	branch string // Entry with siblings below it
+	last   string // Last entry of a directory
+	pipe   string // Continues a parent that has more entries
+	space  string // Below a parent's last entry
+}
+
+var treeStyles = map[string]treeBranches{
+	TreeStyleUnicode: {"├── ", "└── ", "│   ", "    "},
+	TreeStyleASCII:   {"|-- ", "`-- ", "|   ", "    "},
+	TreeStyleIndent:  {"  ", "  ", "  ", "  "},
+}
+
+// treeNode is a directory of the tree being rendered
+type treeNode struct {
+	dirs     map[string]*treeNode
+	files    []scanner.TreeFile
+	excluded string // Why the directory was skipped, if it was
+
+	// Totals for every file below the directory
+	count  int
+	size   int64
+	tokens int
+}
+
+func newTreeNode() *treeNode {
+	return &treeNode{dirs: make(map[string]*treeNode)}
+}
+
+// child returns the subdirectory called name, creating it if needed
+func (n *treeNode) child(name string) *treeNode {
+	node, ok := n.dirs[name]
+	if !ok {
+		node = newTreeNode()
+		n.dirs[name] = node
+	}
+	return node
+}
+
+// add counts file in the directory's totals
+func (n *treeNode) add(file scanner.TreeFile) {
+	n.count++
+	n.size += file.Size
+	n.tokens += file.Tokens
+}
+
+// RenderTree draws the directory tree as text
+// Directories come first, then files, each sorted by name
+func RenderTree(tree *scanner.Tree, opts types.OutputOptions) string {
+	if tree == nil || len(tree.Files) == 0 {
 		return ""
 	}
 
-	// Determine project root name
-	projectRoot := "project"
-	if len(files) > 0 && files[0].Path != "" {
-		dir := filepath.Dir(files[0].Path)
-		if dir != "." && dir != "/" {
-			projectRoot = filepath.Base(dir)
+	root := newTreeNode()
+	for _, file := range tree.Files {
+		parts := strings.Split(filepath.ToSlash(file.Path), "/")
+		node := root
+		node.add(file)
+		for _, dir := range parts[:len(parts)-1] {
+			node = node.child(dir)
+			node.add(file)
+		}
+		node.files = append(node.files, file)
+	}
+	if opts.TreeExcluded {
+		for _, dir := range tree.Excluded {
+			node := root
+			for _, part := range strings.Split(filepath.ToSlash(dir.Path), "/") {
+				node = node.child(part)
+			}
+			node.excluded = dir.Reason
 		}
 	}
 
-	// Build directory structure
-	var result strings.Builder
-	result.WriteString(projectRoot + "/\n")
+	style, ok := treeStyles[opts.TreeStyle]
+	if !ok {
+		style = treeStyles[TreeStyleUnicode]
+	}
+	r := &treeRenderer{opts: opts, style: style}
+
+	name := tree.Root
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = "project"
+	}
+	r.b.WriteString(name + "/" + r.dirNote(root) + "\n")
+	r.writeChildren(root, "", 1)
+
+	return r.b.String()
+}
 
-	// Track processed paths to avoid duplicates
-	processed := make(map[string]bool)
+type treeRenderer struct {
+	b     strings.Builder
+	opts  types.OutputOptions
+	style treeBranches
+}
 
-	for _, file := range files {
-		parts := strings.Split(file.RelativePath, string(filepath.Separator))
+// writeChildren writes the entries of node, which sit at depth
+func (r *treeRenderer) writeChildren(node *treeNode, prefix string, depth int) {
+	if r.opts.TreeDepth > 0 && depth > r.opts.TreeDepth {
+		// Collapse what is below the depth limit into one line
+		if node.count > 0 {
+			r.b.WriteString(fmt.Sprintf("%s%s... (%d files)\n", prefix, r.style.last, node.count))
+		}
+		return
+	}
 
-		// Build each level of the path
-		for i := range parts {
-			pathSoFar := strings.Join(parts[:i+1], "/")
+	dirs := make([]string, 0, len(node.dirs))
+	for dir := range node.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	sort.Slice(node.files, func(i, j int) bool {
+		return node.files[i].Path < node.files[j].Path
+	})
 
-			if !processed[pathSoFar] {
-				processed[pathSoFar] = true
-				indent := strings.Repeat("  ", i+1)
+	remaining := len(dirs) + len(node.files)
+	branch := func() (string, string) {
+		remaining--
+		if remaining == 0 {
+			return r.style.last, r.style.space
+		}
+		return r.style.branch, r.style.pipe
+	}
 
-				if i == len(parts)-1 {
-					// It's a file
-					result.WriteString(fmt.Sprintf("%s%s\n", indent, parts[i]))
-				} else {
-					// It's a directory
-					result.WriteString(fmt.Sprintf("%s%s/\n", indent, parts[i]))
-				}
-			}
+	for _, dir := range dirs {
+		child := node.dirs[dir]
+		connector, indent := branch()
+		r.b.WriteString(prefix + connector + dir + "/" + r.dirNote(child) + "\n")
+		if child.excluded == "" {
+			r.writeChildren(child, prefix+indent, depth+1)
 		}
 	}
+	for _, file := range node.files {
+		connector, _ := branch()
+		r.b.WriteString(prefix + connector + filepath.Base(file.Path) + r.fileNote(file) + "\n")
+	}
+}
+
+// dirNote is the annotation after a directory name
+func (r *treeRenderer) dirNote(node *treeNode) string {
+	if node.excluded != "" {
+		return " (" + node.excluded + ")"
+	}
+	var parts []string
+	if r.opts.TreeCounts {
+		parts = append(parts, fmt.Sprintf("%d files", node.count))
+	}
+	return treeNote(append(parts, r.totals(node.size, node.tokens)...))
+}
+
+// fileNote is the annotation after a file name
+func (r *treeRenderer) fileNote(file scanner.TreeFile) string {
+	return treeNote(r.totals(file.Size, file.Tokens))
+}
+
+func (r *treeRenderer) totals(size int64, tokens int) []string {
+	var parts []string
+	if r.opts.TreeSizes {
+		parts = append(parts, utils.FormatBytes(size))
+	}
+	if r.opts.TreeTokens {
+		parts = append(parts, fmt.Sprintf("~%d tokens", tokens))
+	}
+	return parts
+}
 
-	return result.String()
+func treeNote(parts []string) string {
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
 }
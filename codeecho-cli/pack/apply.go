@@ -0,0 +1,166 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kinds of change apply makes
+const (
+	ChangeCreate = "create"
+	ChangeModify = "modify"
+)
+
+// Change is one file apply would write
+type Change struct {
+	Path   string
+	Kind   string // ChangeCreate or ChangeModify
+	Old    string // Current content; empty when created
+	New    string
+	target string
+}
+
+// ApplyOptions controls how a pack is compared with the working tree
+type ApplyOptions struct {
+	Dir           string // Working tree root
+	CurrentCommit string // Commit checked out in Dir, if known
+	Force         bool   // Plan conflicting files as changes too
+}
+
+// ApplyPlan compares a pack with the working tree
+// Files absent from the pack are left alone, so a partial pack holding
+// only the edited files is enough
+type ApplyPlan struct {
+	Changes   []Change
+	Unchanged []string // Not edited in the pack, or already identical on disk
+	Conflicts []Entry  // Changed on disk since the pack was made; in Changes only with Force
+	Skipped   []Entry  // No content, unsafe path, or content apply would corrupt
+
+	// Commit the pack was made at, when it differs from the working tree's
+	BaseCommit    string
+	CurrentCommit string
+}
+
+// PlanApply works out what applying p to the working tree would change
+// Only files edited in the pack are compared with the working tree
+// Why: Each file's recorded SHA-256 is its base; a file whose hash on disk
+// no longer matches was edited after the pack was made, and overwriting
+// it would silently drop that edit
+func PlanApply(p *Pack, opts ApplyOptions) (*ApplyPlan, error) {
+	root, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ApplyPlan{}
+	if p.Commit != "" && opts.CurrentCommit != "" && !sameCommit(p.Commit, opts.CurrentCommit) {
+		plan.BaseCommit = p.Commit
+		plan.CurrentCommit = opts.CurrentCommit
+	}
+
+	for _, file := range p.Files {
+		if !file.HasContent {
+			continue
+		}
+
+		// Why: Content that still hashes to content_sha256 was not edited,
+		// so there is nothing to apply; comparing it with the disk would
+		// call a later local edit a conflict, and --force would revert it
+		if file.ContentSHA256 != "" && contentMatches(file.Content, file.ContentSHA256) {
+			plan.Unchanged = append(plan.Unchanged, file.Path)
+			continue
+		}
+
+		target, err := safeJoin(root, file.Path)
+		if err != nil {
+			plan.Skipped = append(plan.Skipped, Entry{Path: file.Path, Reason: err.Error()})
+			continue
+		}
+
+		current, err := os.ReadFile(target)
+		exists := err == nil
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Path, err)
+		}
+
+		content := matchLineEndings(restoreContent(file), string(current))
+		if exists && content == string(current) {
+			plan.Unchanged = append(plan.Unchanged, file.Path)
+			continue
+		}
+
+		if reasons := processingReasons(p, file); len(reasons) > 0 {
+			plan.Skipped = append(plan.Skipped, Entry{
+				Path:   file.Path,
+				Reason: "pack content was processed (" + strings.Join(reasons, ", ") + ")",
+			})
+			continue
+		}
+
+		conflict := ""
+		switch {
+		case file.SHA256 != "" && !exists:
+			conflict = "deleted since the pack was made"
+		case file.SHA256 != "" && hashOf(string(current)) != file.SHA256:
+			conflict = "changed since the pack was made"
+		case file.SHA256 == "" && exists:
+			// A file new to the pack, or a pack without hashes
+			conflict = "exists, and the pack records no base hash"
+		}
+		if conflict != "" {
+			plan.Conflicts = append(plan.Conflicts, Entry{Path: file.Path, Reason: conflict})
+			if !opts.Force {
+				continue
+			}
+		}
+
+		change := Change{Path: file.Path, Kind: ChangeCreate, New: content, target: target}
+		if exists {
+			change.Kind = ChangeModify
+			change.Old = string(current)
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	return plan, nil
+}
+
+// Diff renders the planned changes as a patch for git apply or patch -p1
+func (plan *ApplyPlan) Diff() string {
+	var out strings.Builder
+	for _, change := range plan.Changes {
+		oldName := "a/" + change.Path
+		if change.Kind == ChangeCreate {
+			oldName = "/dev/null"
+		}
+		out.WriteString(UnifiedDiff(oldName, "b/"+change.Path, change.Old, change.New))
+	}
+	return out.String()
+}
+
+// Write makes the planned changes
+func (plan *ApplyPlan) Write() error {
+	for _, change := range plan.Changes {
+		if err := writeFile(change.target, change.New); err != nil {
+			return fmt.Errorf("failed to write %s: %w", change.Path, err)
+		}
+	}
+	return nil
+}
+
+// matchLineEndings gives content the CRLF line endings of the file it
+// replaces, for packs whose format lost them
+func matchLineEndings(content, current string) string {
+	if strings.Contains(current, "\r\n") && !strings.Contains(content, "\r\n") {
+		return strings.ReplaceAll(content, "\n", "\r\n")
+	}
+	return content
+}
+
+// sameCommit compares commit hashes that may be abbreviated differently
+func sameCommit(a, b string) bool {
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
@@ -0,0 +1,292 @@
+package pack
+
+import (
+	"sort"
+	"strings"
+)
+
+// File statuses in a comparison
+const (
+	StatusAdded    = "added"
+	StatusRemoved  = "removed"
+	StatusModified = "modified"
+	StatusRenamed  = "renamed"
+)
+
+// DefaultRenameThreshold is the similarity, in percent, a removed and an
+// added file need to count as a rename; git uses the same default
+const DefaultRenameThreshold = 50
+
+// CompareOptions controls Compare
+type CompareOptions struct {
+	RenameThreshold int  // Minimum similarity in percent for a rename
+	Diffs           bool // Include unified diffs of changed text files
+}
+
+// Comparison is what changed between two packs
+type Comparison struct {
+	Old PackSummary `json:"old"`
+	New PackSummary `json:"new"`
+
+	Files     []FileChange    `json:"files"`     // Sorted by path
+	Languages []LanguageDelta `json:"languages"` // Only languages that changed
+
+	Unchanged int `json:"unchanged"`
+}
+
+// PackSummary identifies one side of a comparison
+type PackSummary struct {
+	Path     string `json:"path"`
+	ScanTime string `json:"scan_time,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	Files    int    `json:"files"`
+	Size     int64  `json:"size"`
+	Tokens   int    `json:"tokens"`
+}
+
+// FileChange is one added, removed, modified or renamed file
+type FileChange struct {
+	Status     string `json:"status"`
+	Path       string `json:"path"`
+	OldPath    string `json:"old_path,omitempty"`   // Renames only
+	Similarity int    `json:"similarity,omitempty"` // Renames only, in percent
+	Language   string `json:"language,omitempty"`
+
+	OldSize   int64 `json:"old_size"`
+	NewSize   int64 `json:"new_size"`
+	OldTokens int   `json:"old_tokens"`
+	NewTokens int   `json:"new_tokens"`
+
+	LinesAdded   int    `json:"lines_added"`
+	LinesRemoved int    `json:"lines_removed"`
+	Diff         string `json:"diff,omitempty"`
+}
+
+// LanguageDelta compares one language's totals
+type LanguageDelta struct {
+	Language  string `json:"language"`
+	OldFiles  int    `json:"old_files"`
+	NewFiles  int    `json:"new_files"`
+	OldSize   int64  `json:"old_size"`
+	NewSize   int64  `json:"new_size"`
+	OldTokens int    `json:"old_tokens"`
+	NewTokens int    `json:"new_tokens"`
+}
+
+// Compare reports what changed from oldPack to newPack
+// Why: Release snapshots are compared to write "what changed in the
+// context" notes; renames are matched by content so a moved file is not
+// reported as one removal plus one unrelated addition
+func Compare(oldPack, newPack *Pack, opts CompareOptions) *Comparison {
+	c := &Comparison{Old: summarize(oldPack), New: summarize(newPack)}
+
+	oldFiles := make(map[string]File, len(oldPack.Files))
+	for _, file := range oldPack.Files {
+		oldFiles[file.Path] = file
+	}
+	newFiles := make(map[string]File, len(newPack.Files))
+	for _, file := range newPack.Files {
+		newFiles[file.Path] = file
+	}
+
+	var removed, added []File
+	for _, file := range oldPack.Files {
+		if _, ok := newFiles[file.Path]; !ok {
+			removed = append(removed, file)
+		}
+	}
+	for _, file := range newPack.Files {
+		old, ok := oldFiles[file.Path]
+		switch {
+		case !ok:
+			added = append(added, file)
+		case sameFile(old, file):
+			c.Unchanged++
+		default:
+			c.Files = append(c.Files, fileChange(StatusModified, old, file, opts))
+		}
+	}
+
+	renames, removed, added := matchRenames(removed, added, opts.RenameThreshold)
+	for _, rename := range renames {
+		change := fileChange(StatusRenamed, rename.old, rename.new, opts)
+		change.OldPath = rename.old.Path
+		change.Similarity = rename.similarity
+		c.Files = append(c.Files, change)
+	}
+	for _, file := range removed {
+		c.Files = append(c.Files, fileChange(StatusRemoved, file, File{}, opts))
+	}
+	for _, file := range added {
+		c.Files = append(c.Files, fileChange(StatusAdded, File{}, file, opts))
+	}
+
+	sort.Slice(c.Files, func(i, j int) bool { return c.Files[i].Path < c.Files[j].Path })
+	c.Languages = languageDeltas(oldPack, newPack)
+	return c
+}
+
+func summarize(p *Pack) PackSummary {
+	summary := PackSummary{Path: p.RepoPath, ScanTime: p.ScanTime, Commit: p.Commit, Files: len(p.Files)}
+	for _, file := range p.Files {
+		summary.Size += file.Size
+		summary.Tokens += file.Tokens
+	}
+	return summary
+}
+
+// sameFile compares by original hash when both packs recorded one, since
+// content may be missing or processed differently
+func sameFile(a, b File) bool {
+	if a.SHA256 != "" && b.SHA256 != "" {
+		return a.SHA256 == b.SHA256
+	}
+	if a.HasContent && b.HasContent {
+		return a.Content == b.Content
+	}
+	return a.Size == b.Size
+}
+
+func fileChange(status string, old, new File, opts CompareOptions) FileChange {
+	change := FileChange{
+		Status:    status,
+		Path:      new.Path,
+		Language:  new.Language,
+		OldSize:   old.Size,
+		NewSize:   new.Size,
+		OldTokens: old.Tokens,
+		NewTokens: new.Tokens,
+	}
+	if status == StatusRemoved {
+		change.Path = old.Path
+		change.Language = old.Language
+	}
+
+	// Only text on both sides (or one side missing) gives a line diff
+	if (status == StatusAdded || old.HasContent) && (status == StatusRemoved || new.HasContent) {
+		change.LinesAdded, change.LinesRemoved = lineChanges(old.Content, new.Content)
+		if opts.Diffs {
+			oldName, newName := "a/"+old.Path, "b/"+new.Path
+			if status == StatusAdded {
+				oldName = "/dev/null"
+			}
+			if status == StatusRemoved {
+				newName = "/dev/null"
+			}
+			change.Diff = UnifiedDiff(oldName, newName, old.Content, new.Content)
+		}
+	}
+	return change
+}
+
+type rename struct {
+	old, new   File
+	similarity int
+}
+
+// matchRenames pairs removed and added files by content similarity, best
+// pairs first, and returns the files left unpaired
+func matchRenames(removed, added []File, threshold int) ([]rename, []File, []File) {
+	var candidates []rename
+	for _, old := range removed {
+		for _, new := range added {
+			if score := similarity(old, new); score >= threshold {
+				candidates = append(candidates, rename{old: old, new: new, similarity: score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+
+	usedOld := make(map[string]bool)
+	usedNew := make(map[string]bool)
+	var renames []rename
+	for _, candidate := range candidates {
+		if usedOld[candidate.old.Path] || usedNew[candidate.new.Path] {
+			continue
+		}
+		usedOld[candidate.old.Path] = true
+		usedNew[candidate.new.Path] = true
+		renames = append(renames, candidate)
+	}
+
+	var restRemoved, restAdded []File
+	for _, file := range removed {
+		if !usedOld[file.Path] {
+			restRemoved = append(restRemoved, file)
+		}
+	}
+	for _, file := range added {
+		if !usedNew[file.Path] {
+			restAdded = append(restAdded, file)
+		}
+	}
+	return renames, restRemoved, restAdded
+}
+
+// similarity is the percentage of lines two files share
+// Files without content only match on an identical hash
+func similarity(a, b File) int {
+	if a.SHA256 != "" && a.SHA256 == b.SHA256 {
+		return 100
+	}
+	if !a.HasContent || !b.HasContent {
+		return 0
+	}
+	if a.Content == b.Content {
+		return 100
+	}
+
+	aLines, bLines := splitLines(a.Content), splitLines(b.Content)
+	if len(aLines)+len(bLines) == 0 {
+		return 0
+	}
+	counts := make(map[string]int, len(aLines))
+	for _, line := range aLines {
+		counts[strings.TrimSpace(line)]++
+	}
+	common := 0
+	for _, line := range bLines {
+		key := strings.TrimSpace(line)
+		if counts[key] > 0 {
+			counts[key]--
+			common++
+		}
+	}
+	return 200 * common / (len(aLines) + len(bLines))
+}
+
+func languageDeltas(oldPack, newPack *Pack) []LanguageDelta {
+	deltas := make(map[string]*LanguageDelta)
+	get := func(language string) *LanguageDelta {
+		if language == "" {
+			language = "Other"
+		}
+		if deltas[language] == nil {
+			deltas[language] = &LanguageDelta{Language: language}
+		}
+		return deltas[language]
+	}
+	for _, file := range oldPack.Files {
+		delta := get(file.Language)
+		delta.OldFiles++
+		delta.OldSize += file.Size
+		delta.OldTokens += file.Tokens
+	}
+	for _, file := range newPack.Files {
+		delta := get(file.Language)
+		delta.NewFiles++
+		delta.NewSize += file.Size
+		delta.NewTokens += file.Tokens
+	}
+
+	var result []LanguageDelta
+	for _, delta := range deltas {
+		if delta.OldFiles != delta.NewFiles || delta.OldSize != delta.NewSize || delta.OldTokens != delta.NewTokens {
+			result = append(result, *delta)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Language < result[j].Language })
+	return result
+}
@@ -0,0 +1,226 @@
+package pack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lines of unchanged context around each hunk, as in diff -u and git
+const diffContext = 3
+
+// Edit distance beyond which a diff is shown as a full replacement
+// Why: Myers keeps one row per edit; two unrelated large files would
+// otherwise need memory quadratic in their size
+const maxDiffEdits = 4000
+
+// diffLine is one line of an edit script
+// Text keeps its trailing newline so a missing final newline is a change
+type diffLine struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+// UnifiedDiff returns a unified diff from oldContent to newContent
+// The file headers are "--- oldName" and "+++ newName"; use /dev/null for
+// a side that does not exist. It returns "" when the contents are equal
+func UnifiedDiff(oldName, newName, oldContent, newContent string) string {
+	if oldContent == newContent {
+		return ""
+	}
+
+	script := diffScript(splitLines(oldContent), splitLines(newContent))
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+	for _, hunk := range hunks(script) {
+		writeHunk(&out, script, hunk)
+	}
+	return out.String()
+}
+
+// lineChanges counts the lines added and removed between two contents
+func lineChanges(oldContent, newContent string) (added, removed int) {
+	if oldContent == newContent {
+		return 0, 0
+	}
+	for _, line := range diffScript(splitLines(oldContent), splitLines(newContent)) {
+		switch line.op {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// splitLines splits content after each newline, keeping the newlines
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffScript computes a shortest edit script with Myers' algorithm
+func diffScript(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] holds v for diagonals -d-1..d+1 before step d
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		if d > maxDiffEdits {
+			return replaceScript(a, b)
+		}
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Down: insert from b
+			} else {
+				x = v[offset+k-1] + 1 // Right: delete from a
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return replaceScript(a, b)
+}
+
+// backtrack walks the trace from the end to recover the edit script
+func backtrack(trace [][]int, a, b []string) []diffLine {
+	x, y := len(a), len(b)
+	var reversed []diffLine
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		row := trace[d]
+		at := func(k int) int { return row[k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffLine{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			reversed = append(reversed, diffLine{'+', b[y-1]})
+			y--
+		} else {
+			reversed = append(reversed, diffLine{'-', a[x-1]})
+			x--
+		}
+	}
+
+	script := make([]diffLine, len(reversed))
+	for i, line := range reversed {
+		script[len(reversed)-1-i] = line
+	}
+	return script
+}
+
+// replaceScript deletes every line of a and inserts every line of b
+func replaceScript(a, b []string) []diffLine {
+	script := make([]diffLine, 0, len(a)+len(b))
+	for _, line := range a {
+		script = append(script, diffLine{'-', line})
+	}
+	for _, line := range b {
+		script = append(script, diffLine{'+', line})
+	}
+	return script
+}
+
+// hunks groups changes with their context; each is a [start, end) range
+// of the script. Changes closer than twice the context share a hunk
+func hunks(script []diffLine) [][2]int {
+	var result [][2]int
+	for i := 0; i < len(script); i++ {
+		if script[i].op == ' ' {
+			continue
+		}
+
+		start := max(i-diffContext, 0)
+		end := i
+		for j := i; j < len(script); j++ {
+			if script[j].op != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end = min(end+diffContext, len(script))
+
+		result = append(result, [2]int{start, end})
+		i = end - 1
+	}
+	return result
+}
+
+func writeHunk(out *strings.Builder, script []diffLine, hunk [2]int) {
+	// Line numbers where the hunk starts on each side
+	oldLine, newLine := 1, 1
+	for _, line := range script[:hunk[0]] {
+		if line.op != '+' {
+			oldLine++
+		}
+		if line.op != '-' {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, line := range script[hunk[0]:hunk[1]] {
+		if line.op != '+' {
+			oldCount++
+		}
+		if line.op != '-' {
+			newCount++
+		}
+	}
+
+	out.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount)))
+	for _, line := range script[hunk[0]:hunk[1]] {
+		out.WriteByte(line.op)
+		out.WriteString(line.text)
+		if !strings.HasSuffix(line.text, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats "start,count"; an empty range names the line before it
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,165 @@
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+)
+
+// jsonFile holds the FileInfo fields unpacking needs
+// Content is omitted when empty, so an empty original is told apart from a
+// structure-only entry by its size
+type jsonFile struct {
+	Path          string `json:"relative_path"`
+	Content       string `json:"content"`
+	Language      string `json:"language"`
+	Size          int64  `json:"size"`
+	Tokens        int    `json:"token_count"`
+	IsText        bool   `json:"is_text"`
+	Sampled       bool   `json:"sampled"`
+	Flattened     bool   `json:"flattened"`
+	Encoding      string `json:"encoding"`
+	LineEnding    string `json:"line_ending"`
+	SHA256        string `json:"sha256"`
+	ContentSHA256 string `json:"content_sha256"`
+}
+
+type jsonGit struct {
+	CommitHash string `json:"commit_hash"`
+}
+
+func (f jsonFile) toFile() File {
+	return File{
+		Path:          f.Path,
+		Content:       f.Content,
+		HasContent:    f.IsText && (f.Content != "" || f.Size == 0),
+		IsText:        f.IsText,
+		Language:      scanner.LanguageDisplayName(f.Language),
+		Size:          f.Size,
+		Tokens:        f.Tokens,
+		Sampled:       f.Sampled,
+		Flattened:     f.Flattened,
+		Encoding:      f.Encoding,
+		LineEnding:    f.LineEnding,
+		SHA256:        f.SHA256,
+		ContentSHA256: f.ContentSHA256,
+	}
+}
+
+func parseJSON(data []byte) (*Pack, error) {
+	var doc struct {
+		SchemaVersion string     `json:"schema_version"`
+		RepoPath      string     `json:"repo_path"`
+		ScanTime      string     `json:"scan_time"`
+		Git           jsonGit    `json:"git"`
+		Files         []jsonFile `json:"files"`
+		Manifest      struct {
+			Files  int    `json:"files"`
+			Digest string `json:"digest"`
+		} `json:"manifest"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	p := &Pack{
+		SchemaVersion:  doc.SchemaVersion,
+		RepoPath:       doc.RepoPath,
+		ScanTime:       doc.ScanTime,
+		Commit:         doc.Git.CommitHash,
+		ManifestFiles:  doc.Manifest.Files,
+		ManifestDigest: doc.Manifest.Digest,
+	}
+	for _, f := range doc.Files {
+		p.Files = append(p.Files, f.toFile())
+	}
+	return p, nil
+}
+
+// parseJSONL reads the file-shaped JSONL format
+// Chat-shaped records carry no paths, so such a pack is an error
+func parseJSONL(data []byte) (*Pack, error) {
+	p := &Pack{}
+	sawStats := false
+
+	lines := bufio.NewScanner(bytes.NewReader(data))
+	lines.Buffer(make([]byte, 0, 1024*1024), 1<<30)
+	lineNumber := 0
+	for lines.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(lines.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var record struct {
+			Type          string  `json:"type"`
+			SchemaVersion string  `json:"schema_version"`
+			RepoPath      string  `json:"repo_path"`
+			ScanTime      string  `json:"scan_time"`
+			Git           jsonGit `json:"git"`
+			Path          string  `json:"path"`
+			Content       string  `json:"content"`
+			Language      string  `json:"language"`
+			Size          int64   `json:"size"`
+			Tokens        int     `json:"tokens"`
+			IsText        bool    `json:"is_text"`
+			Sampled       bool    `json:"sampled"`
+			Flattened     bool    `json:"flattened"`
+			SHA256        string  `json:"sha256"`
+			ContentSHA256 string  `json:"content_sha256"`
+
+			TotalFiles     int    `json:"total_files"`
+			ManifestDigest string `json:"manifest_digest"`
+
+			// Only in --jsonl-shape openai-chat records
+			Messages json.RawMessage `json:"messages"`
+		}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		if record.Type == "" && record.Messages != nil {
+			return nil, fmt.Errorf("line %d: pack uses the openai-chat JSONL shape, which records no file paths and cannot be restored", lineNumber)
+		}
+
+		switch record.Type {
+		case "metadata":
+			p.SchemaVersion = record.SchemaVersion
+			p.RepoPath = record.RepoPath
+			p.ScanTime = record.ScanTime
+			p.Commit = record.Git.CommitHash
+		case "file":
+			p.Files = append(p.Files, jsonFile{
+				Path:          record.Path,
+				Content:       record.Content,
+				Language:      record.Language,
+				Size:          record.Size,
+				Tokens:        record.Tokens,
+				IsText:        record.IsText,
+				Sampled:       record.Sampled,
+				Flattened:     record.Flattened,
+				SHA256:        record.SHA256,
+				ContentSHA256: record.ContentSHA256,
+			}.toFile())
+		case "stats":
+			sawStats = true
+			p.ManifestFiles = record.TotalFiles
+			p.ManifestDigest = record.ManifestDigest
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	// Why: Records without a known type are skipped, so a file that is
+	// JSONL but not a pack would otherwise read as an empty one
+	if len(p.Files) == 0 && !(sawStats && p.ManifestFiles == 0) {
+		return nil, fmt.Errorf("no file records found; not a CodeEcho JSONL pack")
+	}
+
+	return p, nil
+}
@@ -0,0 +1,184 @@
+package pack
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	markdownRepoPattern          = regexp.MustCompile(`(?m)^\*\*Repository:\*\* (.*)$`)
+	markdownScanTimePattern      = regexp.MustCompile(`(?m)^\*\*Scan Time:\*\* (.*)$`)
+	markdownCommitPattern        = regexp.MustCompile(`(?m)^\*\*Commit:\*\* (.*)$`)
+	markdownSchemaPattern        = regexp.MustCompile(`(?m)^\*\*Schema Version:\*\* (.*)$`)
+	markdownSHA256Pattern        = regexp.MustCompile("\\*\\*SHA-256:\\*\\* `([0-9a-f]+)`")
+	markdownSizePattern          = regexp.MustCompile(`\*\*Size:\*\* ([^|]+)`)
+	markdownLanguagePattern      = regexp.MustCompile(`\*\*Language:\*\* ([^|(]+)`)
+	markdownContentSHA256Pattern = regexp.MustCompile("\\*\\*Content SHA-256:\\*\\* `([0-9a-f]+)`")
+	markdownManifestPattern      = regexp.MustCompile("(?m)^- \\*\\*Manifest:\\*\\* (\\d+) files, `([^`]+)`")
+	markdownEncodingPattern      = regexp.MustCompile(`\*\*Encoding:\*\* ([^ |]+)`)
+	markdownEndingPattern        = regexp.MustCompile(`\*\*Line Endings:\*\* ([^ |]+)`)
+	markdownAnchorPattern        = regexp.MustCompile(`^<a id="[^"]*"></a>\n\n`)
+)
+
+// parseMarkdown reads the Markdown format
+// Each file is a "### path" section, optionally after an anchor line: a
+// metadata line, then a fenced code block or an italic note, then a "---"
+// separator
+func parseMarkdown(data []byte) (*Pack, error) {
+	text := string(data)
+	p := &Pack{}
+
+	// Metadata patterns only look above the files, never into content
+	header, rest, found := strings.Cut(text, "\n## Files\n")
+	if match := markdownRepoPattern.FindStringSubmatch(header); match != nil {
+		p.RepoPath = strings.TrimSpace(match[1])
+	}
+	if match := markdownScanTimePattern.FindStringSubmatch(header); match != nil {
+		p.ScanTime = strings.TrimSpace(match[1])
+	}
+	if match := markdownCommitPattern.FindStringSubmatch(header); match != nil {
+		p.Commit = strings.TrimSpace(match[1])
+	}
+	if match := markdownSchemaPattern.FindStringSubmatch(header); match != nil {
+		p.SchemaVersion = strings.TrimSpace(match[1])
+	}
+	if !found {
+		return p, nil
+	}
+
+	// The statistics follow the last file, so look after the last heading
+	// and read files only before it
+	if index := strings.LastIndex(rest, "\n## Scan Statistics\n"); index >= 0 {
+		if match := markdownManifestPattern.FindStringSubmatch(rest[index:]); match != nil {
+			p.ManifestFiles, _ = strconv.Atoi(match[1])
+			p.ManifestDigest = match[2]
+		}
+		rest = rest[:index+1]
+	}
+
+	for {
+		start := sectionStart(rest, "### ")
+		if start < 0 {
+			break
+		}
+		rest = rest[start+len("### "):]
+
+		var file File
+		var ok bool
+		file, rest, ok = parseMarkdownFile(rest)
+		if !ok {
+			break
+		}
+		p.Files = append(p.Files, file)
+	}
+
+	return p, nil
+}
+
+// parseMarkdownFile reads one section, starting just after "### "
+// It returns the file and the text after the section's separator
+func parseMarkdownFile(section string) (File, string, bool) {
+	path, rest, ok := strings.Cut(section, "\n")
+	if !ok {
+		return File{}, "", false
+	}
+	rest = strings.TrimLeft(rest, "\n")
+
+	metadata, rest, _ := strings.Cut(rest, "\n")
+	rest = strings.TrimLeft(rest, "\n")
+
+	file := File{
+		Path:      path,
+		IsText:    strings.Contains(metadata, "**Text File:** true"),
+		Sampled:   strings.Contains(metadata, "(sampled from "),
+		Flattened: strings.Contains(metadata, "**Notebook:** flattened"),
+	}
+	if match := markdownEncodingPattern.FindStringSubmatch(metadata); match != nil {
+		file.Encoding = match[1]
+	}
+	if match := markdownEndingPattern.FindStringSubmatch(metadata); match != nil {
+		file.LineEnding = match[1]
+	}
+	if match := markdownSizePattern.FindStringSubmatch(metadata); match != nil {
+		file.Size = parseSize(match[1])
+	}
+	if match := markdownLanguagePattern.FindStringSubmatch(metadata); match != nil {
+		file.Language = strings.TrimSpace(match[1])
+	}
+	if match := markdownSHA256Pattern.FindStringSubmatch(metadata); match != nil {
+		file.SHA256 = match[1]
+	}
+	if match := markdownContentSHA256Pattern.FindStringSubmatch(metadata); match != nil {
+		file.ContentSHA256 = match[1]
+	}
+
+	if strings.HasPrefix(rest, "```") {
+		// The fence is whatever run of backticks opened the block
+		opening, body, _ := strings.Cut(rest, "\n")
+		fence := opening[:len(opening)-len(strings.TrimLeft(opening, "`"))]
+
+		// Content may itself hold a fence; the real end is the one followed
+		// by the separator and the next section (or the end of the pack)
+		closing := "\n" + fence + "\n\n---\n\n"
+		offset := 0
+		for {
+			end := strings.Index(body[offset:], closing)
+			if end < 0 {
+				return file, "", false
+			}
+			end += offset
+			after := body[end+len(closing):]
+			if isSectionBoundary(after) {
+				file.Content = body[:end]
+				file.HasContent = true
+				return file, after, true
+			}
+			offset = end + 1
+		}
+	}
+
+	// A note instead of content: the binary description, or "Content not
+	// included", which the writer also prints for empty files
+	if file.IsText && strings.HasPrefix(rest, "*Content not included*") &&
+		strings.HasPrefix(metadata, "**Size:** 0 B") {
+		file.HasContent = true
+	}
+	if end := sectionStart(rest, "---\n"); end >= 0 {
+		// Binary files list their hash below the note
+		if match := markdownSHA256Pattern.FindStringSubmatch(rest[:end]); match != nil && file.SHA256 == "" {
+			file.SHA256 = match[1]
+		}
+		rest = rest[end:]
+	}
+	return file, rest, true
+}
+
+// isSectionBoundary reports whether text starts where the writer puts what
+// follows a file: another file section with its metadata line, the
+// statistics, or the end of the pack
+func isSectionBoundary(text string) bool {
+	if match := markdownAnchorPattern.FindString(text); match != "" {
+		text = text[len(match):]
+	}
+	if text == "" || strings.HasPrefix(text, "## Scan Statistics\n") {
+		return true
+	}
+	if !strings.HasPrefix(text, "### ") {
+		return false
+	}
+	_, rest, _ := strings.Cut(text, "\n")
+	return strings.HasPrefix(rest, "\n**Size:** ")
+}
+
+// sectionStart finds prefix at the start of a line
+func sectionStart(text, prefix string) int {
+	if strings.HasPrefix(text, prefix) {
+		return 0
+	}
+	index := strings.Index(text, "\n"+prefix)
+	if index < 0 {
+		return -1
+	}
+	return index + 1
+}
@@ -0,0 +1,226 @@
+// Package pack reads CodeEcho output back into files
+package pack
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/output"
+	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
+)
+
+// Formats Read understands
+const (
+	FormatXML       = "xml"
+	FormatClaudeXML = "claude-xml"
+	FormatJSON      = "json"
+	FormatJSONL     = "jsonl"
+	FormatMarkdown  = "markdown"
+)
+
+// Pack is a parsed CodeEcho output file
+type Pack struct {
+	Format   string
+	RepoPath string
+	ScanTime string
+	Commit   string // Short git commit hash the pack was made at, if any
+
+	// Output schema version; empty for packs made before versioning
+	SchemaVersion string
+
+	// Manifest from the pack's footer; empty for packs made before manifests
+	ManifestFiles  int
+	ManifestDigest string
+
+	// Processing applied when the pack was made ("comments removed", ...)
+	// Only the XML format records it
+	Processing []string
+
+	Files []File
+}
+
+// File is one file entry of a pack
+type File struct {
+	Path       string
+	Content    string
+	HasContent bool // False for binary files and structure-only packs
+	IsText     bool
+	Language   string // Display name, e.g. "Go"
+	Size       int64  // Original size; approximate when the pack only has "1.2 KB"
+	Tokens     int    // Estimated from content when the pack does not say
+
+	// What the pack says about the original, used to judge whether the
+	// content can be restored byte-exact
+	Sampled    bool
+	Flattened  bool // A notebook flattened to source
+	Encoding   string
+	LineEnding string
+	SHA256     string // Hash of the original file, when recorded
+
+	// Hash of the content as packed, for detecting edits to the pack
+	ContentSHA256 string
+
+	// Line numbers (--line-numbers) were found and removed
+	LineNumbersStripped bool
+}
+
+// Read parses the pack at path, detecting its format
+func Read(path string) (*Pack, error) {
+	data, err := ReadData(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, DetectFormat(path, data))
+}
+
+// ReadData reads the pack at path, decompressing gzip and zstd packs
+func ReadData(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack: %w", err)
+	}
+	data, err = utils.Decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack: %w", err)
+	}
+	return data, nil
+}
+
+// Parse reads pack data in the given format
+func Parse(data []byte, format string) (*Pack, error) {
+	var p *Pack
+	var err error
+
+	switch format {
+	case FormatXML:
+		p, err = parseXML(data)
+	case FormatClaudeXML:
+		p, err = parseClaudeXML(data)
+	case FormatJSON:
+		p, err = parseJSON(data)
+	case FormatJSONL:
+		p, err = parseJSONL(data)
+	case FormatMarkdown:
+		p, err = parseMarkdown(data)
+	default:
+		return nil, fmt.Errorf("unsupported pack format: %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s pack: %w", format, err)
+	}
+
+	if p.SchemaVersion != "" {
+		if err := checkSchemaVersion(p.SchemaVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	p.Format = format
+	for i := range p.Files {
+		file := &p.Files[i]
+		if !file.HasContent {
+			continue
+		}
+		file.Content, file.LineNumbersStripped = stripLineNumbers(file.Content)
+		if file.Size == 0 {
+			file.Size = int64(len(file.Content))
+		}
+		if file.Tokens == 0 {
+			file.Tokens = utils.EstimateTokens(file.Content)
+		}
+	}
+	return p, nil
+}
+
+// checkSchemaVersion rejects packs from a newer major schema version
+// Why: Minor versions only add fields, which the parsers ignore, but a new
+// major may rename or retype fields and parse into silently wrong files
+func checkSchemaVersion(version string) error {
+	major, err := output.SchemaMajor(version)
+	if err != nil {
+		return err
+	}
+	supported, _ := output.SchemaMajor(output.SchemaVersion)
+	if major > supported {
+		return fmt.Errorf("pack uses schema version %s; this codeecho reads up to %d.x, upgrade to read it", version, supported)
+	}
+	return nil
+}
+
+// DetectFormat guesses the format from the extension, then from the content
+// data must already be decompressed; a .gz or .zst suffix is looked past
+func DetectFormat(path string, data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+
+	switch strings.ToLower(filepath.Ext(utils.TrimCompressionExt(path))) {
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	case ".json":
+		return FormatJSON
+	case ".md", ".markdown":
+		return FormatMarkdown
+	case ".xml":
+		if bytes.HasPrefix(trimmed, []byte("<documents>")) {
+			return FormatClaudeXML
+		}
+		return FormatXML
+	}
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<documents>")):
+		return FormatClaudeXML
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return FormatXML
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		// JSON is indented, so its first line is just "{"
+		if firstLine, _, _ := bytes.Cut(trimmed, []byte("\n")); len(bytes.TrimSpace(firstLine)) > 1 {
+			return FormatJSONL
+		}
+		return FormatJSON
+	}
+	return FormatMarkdown
+}
+
+var lineNumberPattern = regexp.MustCompile(`^ *(\d+): ?`)
+
+// stripLineNumbers removes "   1: " prefixes added by --line-numbers
+// Every line must carry the next number in sequence, so ordinary content
+// that happens to start with digits is left alone
+func stripLineNumbers(content string) (string, bool) {
+	if content == "" {
+		return content, false
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		match := lineNumberPattern.FindStringSubmatch(line)
+		if match == nil {
+			return content, false
+		}
+		if n, _ := strconv.Atoi(match[1]); n != i+1 {
+			return content, false
+		}
+		lines[i] = line[len(match[0]):]
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// parseSize reads a size written by utils.FormatBytes, e.g. "1.2 KB"
+func parseSize(formatted string) int64 {
+	number, unit, _ := strings.Cut(strings.TrimSpace(formatted), " ")
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0
+	}
+	exponent := strings.Index("BKMGTPE", unit[:min(len(unit), 1)])
+	if exponent < 0 {
+		return 0
+	}
+	return int64(value * math.Pow(1024, float64(exponent)))
+}
@@ -0,0 +1,222 @@
+package pack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Overwrite policies for files that already exist in the target directory
+const (
+	OverwriteSkip   = "skip"   // Keep the existing file
+	OverwriteAlways = "always" // Replace it
+	OverwriteFail   = "fail"   // Stop before writing anything
+)
+
+// ValidOverwrite reports whether policy is a known overwrite policy
+func ValidOverwrite(policy string) bool {
+	switch policy {
+	case OverwriteSkip, OverwriteAlways, OverwriteFail:
+		return true
+	}
+	return false
+}
+
+// UnpackOptions controls how a pack is written out
+type UnpackOptions struct {
+	Dir       string // Target directory, created if missing
+	DryRun    bool   // Report what would happen without writing
+	Overwrite string // One of the Overwrite* policies; empty means skip
+}
+
+// Entry is a file listed in a report, with the reason it is listed
+type Entry struct {
+	Path   string
+	Reason string
+}
+
+// UnpackReport says what Unpack did with each file
+type UnpackReport struct {
+	Written []string
+	Skipped []Entry // Not written: no content, unsafe path or existing file
+	Inexact []Entry // Written, but not byte-identical to the original
+
+	// Written files the pack holds no hash for, so exactness is assumed
+	Unverified int
+}
+
+// Unpack writes the files of p under opts.Dir
+// Why: A pack is often the only copy an LLM or a reviewer edited; turning it
+// back into a tree makes those edits usable. Files whose content was changed
+// when the pack was made are written but reported, since they cannot be
+// restored exactly
+func Unpack(p *Pack, opts UnpackOptions) (*UnpackReport, error) {
+	if opts.Overwrite == "" {
+		opts.Overwrite = OverwriteSkip
+	}
+	if !ValidOverwrite(opts.Overwrite) {
+		return nil, fmt.Errorf("invalid overwrite policy: %q (use skip, always or fail)", opts.Overwrite)
+	}
+
+	root, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UnpackReport{}
+	targets := make([]string, len(p.Files))
+	pathErrors := make([]error, len(p.Files))
+
+	// Resolve every path first so "fail" stops before anything is written
+	for i, file := range p.Files {
+		target, err := safeJoin(root, file.Path)
+		if err != nil {
+			pathErrors[i] = err
+			continue
+		}
+		targets[i] = target
+
+		if opts.Overwrite == OverwriteFail && file.HasContent {
+			if _, err := os.Lstat(target); err == nil {
+				return nil, fmt.Errorf("%s already exists (use --overwrite always or skip)", file.Path)
+			}
+		}
+	}
+
+	for i, file := range p.Files {
+		if pathErrors[i] != nil {
+			report.Skipped = append(report.Skipped, Entry{Path: file.Path, Reason: pathErrors[i].Error()})
+			continue
+		}
+		if !file.HasContent {
+			reason := "content not included"
+			if !file.IsText {
+				reason = "binary file"
+			}
+			report.Skipped = append(report.Skipped, Entry{Path: file.Path, Reason: reason})
+			continue
+		}
+
+		if _, err := os.Lstat(targets[i]); err == nil && opts.Overwrite == OverwriteSkip {
+			report.Skipped = append(report.Skipped, Entry{Path: file.Path, Reason: "already exists"})
+			continue
+		}
+
+		content := restoreContent(file)
+		if !opts.DryRun {
+			if err := writeFile(targets[i], content); err != nil {
+				return report, fmt.Errorf("failed to write %s: %w", file.Path, err)
+			}
+		}
+		report.Written = append(report.Written, file.Path)
+
+		if reason := inexactReason(p, file, content); reason != "" {
+			report.Inexact = append(report.Inexact, Entry{Path: file.Path, Reason: reason})
+		} else if file.SHA256 == "" {
+			report.Unverified++
+		}
+	}
+
+	return report, nil
+}
+
+// safeJoin places a pack path under root
+// Paths come from a file anyone may have edited: absolute paths, ".." and
+// symlinked directories could otherwise write outside the target
+func safeJoin(root, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("empty path")
+	}
+	cleaned := filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(cleaned) || filepath.VolumeName(cleaned) != "" || strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("absolute path")
+	}
+	if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path escapes the target directory")
+	}
+
+	target := filepath.Join(root, cleaned)
+
+	// No directory between root and the file may be a symlink
+	dir := root
+	for _, part := range strings.Split(filepath.Dir(cleaned), string(filepath.Separator)) {
+		if part == "." {
+			continue
+		}
+		dir = filepath.Join(dir, part)
+		if info, err := os.Lstat(dir); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("parent directory is a symlink")
+		}
+	}
+	if info, err := os.Lstat(target); err == nil && !info.Mode().IsRegular() {
+		return "", fmt.Errorf("target exists and is not a regular file")
+	}
+
+	return target, nil
+}
+
+// restoreContent undoes what the scanner did to the bytes on the way in
+// The scanner drops a UTF-8 byte order mark when decoding; formats that do
+// not record the encoding still reveal it through the hash
+func restoreContent(file File) string {
+	if strings.HasPrefix(file.Content, "\ufeff") {
+		return file.Content
+	}
+	withBOM := "\ufeff" + file.Content
+	if file.Encoding == "utf-8-bom" || (file.SHA256 != "" && hashOf(withBOM) == file.SHA256) {
+		return withBOM
+	}
+	return file.Content
+}
+
+func hashOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// inexactReason explains why written content may differ from the original
+// It returns "" when the content is known or expected to be identical
+func inexactReason(p *Pack, file File, content string) string {
+	if file.SHA256 != "" && hashOf(content) == file.SHA256 {
+		return ""
+	}
+
+	reasons := processingReasons(p, file)
+	if len(reasons) == 0 && file.SHA256 != "" {
+		reasons = append(reasons, "content does not match the recorded SHA-256")
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// processingReasons lists what the scanner did to the file's content that
+// cannot be undone
+func processingReasons(p *Pack, file File) []string {
+	var reasons []string
+	if file.Sampled {
+		reasons = append(reasons, "data file was sampled")
+	}
+	// Why: Every notebook is flattened, so the extension covers packs
+	// written before the flattened marker and formats without it
+	if file.Flattened || strings.EqualFold(filepath.Ext(file.Path), ".ipynb") {
+		reasons = append(reasons, "notebook was flattened")
+	}
+	for _, option := range p.Processing {
+		if option != "large data files sampled" {
+			reasons = append(reasons, option)
+		}
+	}
+	if file.Encoding != "" && file.Encoding != "utf-8" && file.Encoding != "utf-8-bom" {
+		reasons = append(reasons, "transcoded from "+file.Encoding)
+	}
+	return reasons
+}
+
+func writeFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
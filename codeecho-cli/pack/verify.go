@@ -0,0 +1,121 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+)
+
+// Manifest states in a verify report
+const (
+	ManifestOK       = "ok"
+	ManifestMissing  = "missing"  // Pack made before manifests
+	ManifestMismatch = "mismatch" // Entries added, removed or changed
+)
+
+// VerifyReport is the result of checking a pack
+type VerifyReport struct {
+	Files int
+
+	// Internal integrity
+	ContentChecked int     // Files whose content hash was checked
+	Tampered       []Entry // Content no longer matches its recorded hash
+	Manifest       string  // One of the Manifest* states
+	ManifestDetail string
+
+	// Against a repository, when one was given
+	RepoChecked bool
+	Current     int
+	Stale       []Entry // Changed or deleted on disk since the pack was made
+	Unhashed    int     // No original hash recorded, so not compared
+}
+
+// OK reports whether the pack passed every check that was run
+func (r *VerifyReport) OK() bool {
+	return len(r.Tampered) == 0 && r.Manifest != ManifestMismatch && len(r.Stale) == 0
+}
+
+// Verify checks a pack's content hashes and manifest and, when repoDir is
+// not empty, compares each file's original hash with the file on disk
+// Why: A pack handed to a model or a colleague may be edited by hand or
+// outlive the code it describes; neither shows without the hashes
+func Verify(p *Pack, repoDir string) (*VerifyReport, error) {
+	report := &VerifyReport{Files: len(p.Files)}
+
+	manifest := scanner.NewManifest()
+	for _, file := range p.Files {
+		manifest.Add(file.Path, file.SHA256, file.ContentSHA256)
+
+		if file.HasContent && file.ContentSHA256 != "" {
+			report.ContentChecked++
+			if !contentMatches(file.Content, file.ContentSHA256) {
+				report.Tampered = append(report.Tampered, Entry{Path: file.Path, Reason: "content does not match content_sha256"})
+			}
+		}
+	}
+
+	switch {
+	case p.ManifestDigest == "":
+		report.Manifest = ManifestMissing
+	case p.ManifestFiles != manifest.Files():
+		report.Manifest = ManifestMismatch
+		report.ManifestDetail = fmt.Sprintf("manifest lists %d files, pack holds %d", p.ManifestFiles, manifest.Files())
+	case p.ManifestDigest != manifest.Digest():
+		report.Manifest = ManifestMismatch
+		report.ManifestDetail = "file paths or hashes differ from the manifest"
+	default:
+		report.Manifest = ManifestOK
+	}
+
+	if repoDir == "" {
+		return report, nil
+	}
+
+	root, err := filepath.Abs(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	report.RepoChecked = true
+	for _, file := range p.Files {
+		if file.SHA256 == "" {
+			report.Unhashed++
+			continue
+		}
+
+		target, err := safeJoin(root, file.Path)
+		if err != nil {
+			report.Stale = append(report.Stale, Entry{Path: file.Path, Reason: err.Error()})
+			continue
+		}
+		current, err := os.ReadFile(target)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			report.Stale = append(report.Stale, Entry{Path: file.Path, Reason: "deleted"})
+		case err != nil:
+			return nil, fmt.Errorf("failed to read %s: %w", file.Path, err)
+		case hashOf(string(current)) != file.SHA256:
+			report.Stale = append(report.Stale, Entry{Path: file.Path, Reason: "changed"})
+		default:
+			report.Current++
+		}
+	}
+
+	return report, nil
+}
+
+// contentMatches compares content with its recorded hash
+// Some formats lose CRLF line endings (XML parsers normalise them), so
+// the content is also tried with its line endings converted
+func contentMatches(content, want string) bool {
+	if hashOf(content) == want {
+		return true
+	}
+	if strings.Contains(content, "\r\n") {
+		return hashOf(strings.ReplaceAll(content, "\r\n", "\n")) == want
+	}
+	return hashOf(strings.ReplaceAll(content, "\n", "\r\n")) == want
+}
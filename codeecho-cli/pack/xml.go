@@ -0,0 +1,271 @@
+package pack
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/output"
+	"github.com/NesoHQ/code-echo/codeecho-cli/scanner"
+)
+
+const processingPrefix = "The content has been processed with the following options:"
+
+// parseXML reads the default XML format
+// Content is written between a newline after <file ...> and one before
+// </file>; binary files and structure-only entries hold only comments
+func parseXML(data []byte) (*Pack, error) {
+	p := &Pack{}
+	// Token-by-token, so packs from before the <codebase> root still read
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var file *File
+	var sized bool // The file records its size
+	var content strings.Builder
+	depth := 0      // Element depth inside the current <file>
+	var text string // Text of the current metadata element
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, xmlError(err)
+		}
+
+		switch t := token.(type) {
+		case xml.Comment:
+			comment := strings.TrimSpace(string(t))
+			if file != nil && depth == 0 {
+				// "Binary file - content not included" or "Content not included"
+				file.HasContent = false
+				continue
+			}
+			if rest, ok := strings.CutPrefix(comment, processingPrefix); ok {
+				p.Processing = parseProcessing(rest)
+			}
+
+		case xml.StartElement:
+			if file != nil {
+				depth++
+				continue
+			}
+			text = ""
+			if t.Name.Local == "codebase" {
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "schema_version" {
+						p.SchemaVersion = attr.Value
+					}
+				}
+			}
+			if t.Name.Local == "manifest" {
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "files":
+						p.ManifestFiles, _ = strconv.Atoi(attr.Value)
+					case "digest":
+						p.ManifestDigest = attr.Value
+					}
+				}
+			}
+			if t.Name.Local == "file" {
+				file = &File{HasContent: true, IsText: true}
+				sized = false
+				content.Reset()
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "path":
+						file.Path = attr.Value
+					case "is_text":
+						file.IsText = attr.Value == "true"
+					case "sampled":
+						file.Sampled = attr.Value == "true"
+					case "flattened":
+						file.Flattened = attr.Value == "true"
+					case "encoding":
+						file.Encoding = attr.Value
+					case "line_endings":
+						file.LineEnding = attr.Value
+					case "sha256":
+						file.SHA256 = attr.Value
+					case "content_sha256":
+						file.ContentSHA256 = attr.Value
+					case "size":
+						// Approximate ("1.2 KB"); size_bytes, when present, is exact
+						if !sized {
+							file.Size = parseSize(attr.Value)
+						}
+						sized = true
+					case "size_bytes":
+						file.Size, _ = strconv.ParseInt(attr.Value, 10, 64)
+						sized = true
+					case "language":
+						file.Language = scanner.LanguageDisplayName(attr.Value)
+					}
+				}
+			}
+
+		case xml.CharData:
+			if file != nil && depth == 0 {
+				content.Write(t)
+			} else if file == nil {
+				text += string(t)
+			}
+
+		case xml.EndElement:
+			if file != nil && depth > 0 {
+				depth--
+				continue
+			}
+			switch t.Name.Local {
+			case "file":
+				if !file.IsText {
+					file.HasContent = false
+				} else if sized && file.Size == 0 {
+					// Empty files are written as "Content not included"
+					file.HasContent = true
+				}
+				if file.HasContent {
+					file.Content = trimWrapping(content.String())
+					// XML parsers turn \r\n into \n; put it back
+					if file.LineEnding == "crlf" {
+						file.Content = strings.ReplaceAll(file.Content, "\n", "\r\n")
+					}
+				}
+				p.Files = append(p.Files, *file)
+				file = nil
+			case "repo_path":
+				p.RepoPath = strings.TrimSpace(text)
+			case "scan_time":
+				p.ScanTime = strings.TrimSpace(text)
+			case "commit_hash":
+				p.Commit = strings.TrimSpace(text)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// Sources of the documents the claude-xml writer adds around the files
+var legacyClaudeDocuments = map[string]string{
+	"repository_metadata": output.ClaudeDocumentRepository,
+	"scan_statistics":     output.ClaudeDocumentStatistics,
+	"repository_manifest": output.ClaudeDocumentManifest,
+}
+
+// parseClaudeXML reads the <documents> format
+// Documents with a kind attribute hold the repository metadata, statistics
+// and manifest; the rest are files
+func parseClaudeXML(data []byte) (*Pack, error) {
+	var doc struct {
+		Documents []struct {
+			Kind          string  `xml:"kind,attr"`
+			Source        string  `xml:"source"`
+			Language      string  `xml:"language"`
+			LineCount     *int    `xml:"line_count"`
+			Flattened     bool    `xml:"flattened"`
+			SHA256        string  `xml:"sha256"`
+			ContentSHA256 string  `xml:"content_sha256"`
+			Content       *string `xml:"document_content"`
+		} `xml:"document"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, xmlError(err)
+	}
+
+	// Packs written before the kind attribute mark nothing; there files are
+	// hashed, so a hashless document with a reserved name is one of ours
+	legacy := true
+	for _, d := range doc.Documents {
+		if d.Kind != "" {
+			legacy = false
+			break
+		}
+	}
+
+	p := &Pack{}
+	for _, d := range doc.Documents {
+		kind := d.Kind
+		if legacy && d.SHA256 == "" {
+			kind = legacyClaudeDocuments[d.Source]
+		}
+
+		switch kind {
+		case output.ClaudeDocumentRepository:
+			if d.Content != nil {
+				p.RepoPath = metadataLine(*d.Content, "Repository: ")
+				p.ScanTime = metadataLine(*d.Content, "Scan time: ")
+				p.Commit = metadataLine(*d.Content, "Commit: ")
+				p.SchemaVersion = metadataLine(*d.Content, "Schema version: ")
+			}
+			continue
+		case output.ClaudeDocumentStatistics:
+			continue
+		case output.ClaudeDocumentManifest:
+			if d.Content != nil {
+				p.ManifestFiles, _ = strconv.Atoi(metadataLine(*d.Content, "Files: "))
+				p.ManifestDigest = metadataLine(*d.Content, "Digest: ")
+			}
+			continue
+		}
+
+		file := File{Path: d.Source, IsText: true, Language: d.Language, Flattened: d.Flattened, SHA256: d.SHA256, ContentSHA256: d.ContentSHA256}
+		if d.Content != nil {
+			file.Content = *d.Content
+		}
+		switch {
+		case strings.HasPrefix(file.Content, "Binary file") && strings.HasSuffix(file.Content, "- content not included"):
+			file.IsText = false
+		case file.Content != "":
+			file.HasContent = true
+		default:
+			// Empty content is an empty file only if no line count says otherwise
+			file.HasContent = d.LineCount == nil
+		}
+		p.Files = append(p.Files, file)
+	}
+	return p, nil
+}
+
+// metadataLine finds the value of a "Key: value" line
+func metadataLine(text, prefix string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if value, ok := strings.CutPrefix(line, prefix); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// trimWrapping removes the newline the writer puts on each side of content
+func trimWrapping(s string) string {
+	s = strings.TrimPrefix(s, "\n")
+	return strings.TrimSuffix(s, "\n")
+}
+
+// parseProcessing splits "comments removed, code compressed -->" into options
+func parseProcessing(list string) []string {
+	list = strings.TrimSpace(list)
+	if list == "" || list == "no processing applied" {
+		return nil
+	}
+
+	var options []string
+	for _, option := range strings.Split(list, ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+// xmlError explains the usual cause of a parse failure
+func xmlError(err error) error {
+	return fmt.Errorf("%w (packs made before XML output was escaped, e.g. with --line-numbers, may not be well-formed)", err)
+}
@@ -0,0 +1,57 @@
+package pack
+
+import "testing"
+
+func TestParseClaudeXMLReservedSources(t *testing.T) {
+	tests := []struct {
+		name  string
+		pack  string
+		files []string
+	}{
+		{
+			name: "kind attribute",
+			pack: `<documents>
+<document index="1" kind="repository"><source>repository_metadata</source><document_content><![CDATA[Repository: /repo
+]]></document_content></document>
+<document index="2"><source>repository_metadata</source><document_content><![CDATA[a file]]></document_content></document>
+<document index="3"><source>scan_statistics</source><document_content><![CDATA[another]]></document_content></document>
+<document index="4" kind="statistics"><source>scan_statistics</source><document_content><![CDATA[Total files: 3]]></document_content></document>
+<document index="5" kind="manifest"><source>repository_manifest</source><document_content><![CDATA[Files: 3
+Digest: sha256:abc
+]]></document_content></document>
+</documents>`,
+			files: []string{"repository_metadata", "scan_statistics"},
+		},
+		{
+			name: "hashless documents without kind",
+			pack: `<documents>
+<document index="1"><source>repository_metadata</source><document_content><![CDATA[Repository: /repo
+]]></document_content></document>
+<document index="2"><source>repository_manifest</source><sha256>9f86d081</sha256><document_content><![CDATA[a file]]></document_content></document>
+<document index="3"><source>scan_statistics</source><document_content><![CDATA[Total files: 1]]></document_content></document>
+<document index="4"><source>repository_manifest</source><document_content><![CDATA[Files: 1
+Digest: sha256:abc
+]]></document_content></document>
+</documents>`,
+			files: []string{"repository_manifest"},
+		},
+	}
+
+	for _, tt := range tests {
+		p, err := parseClaudeXML([]byte(tt.pack))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if p.RepoPath != "/repo" || p.ManifestDigest != "sha256:abc" {
+			t.Errorf("%s: repo %q, digest %q", tt.name, p.RepoPath, p.ManifestDigest)
+		}
+		if len(p.Files) != len(tt.files) {
+			t.Fatalf("%s: got %d files, want %v", tt.name, len(p.Files), tt.files)
+		}
+		for i, path := range tt.files {
+			if p.Files[i].Path != path {
+				t.Errorf("%s: file %d is %q, want %q", tt.name, i, p.Files[i].Path, path)
+			}
+		}
+	}
+}
@@ -2,7 +2,6 @@ package scanner
 
 import (
 	"io/fs"
-	"os"
 	"path/filepath"
 	"sort"
 	"time"
@@ -20,8 +19,11 @@ type AnalysisScanner struct {
 	errors           []ScanError
 	startTime        time.Time
 
-	gitignore *ignore.GitIgnore
-	gitMeta   *GitMetadata
+	gitignore  *ignore.GitIgnore
+	gitMeta    *GitMetadata
+	classifier *fileClassifier
+
+	editorConfig *editorConfigResolver
 }
 
 func NewAnalysisScanner(rootPath string, opts ScanOptions) *AnalysisScanner {
@@ -31,6 +33,19 @@ func NewAnalysisScanner(rootPath string, opts ScanOptions) *AnalysisScanner {
 		errors:   []ScanError{},
 	}
 
+	// Load linguist overrides for generated/vendored classification
+	classifier, err := newFileClassifier(rootPath)
+	if err != nil {
+		scanner.errors = append(scanner.errors, ScanError{
+			Path:    filepath.Join(rootPath, ".gitattributes"),
+			Phase:   "gitattributes",
+			Error:   err,
+			Skipped: false,
+		})
+	}
+	scanner.classifier = classifier
+	scanner.editorConfig = newEditorConfigResolver(rootPath)
+
 	// Load Git information if git-aware mode is enabled
 	if opts.GitAware {
 		// Load .gitignore patterns
@@ -176,39 +191,46 @@ func (a *AnalysisScanner) Scan() (*ScanResult, error) {
 				return nil // Continue
 			}
 
-			language := detectLanguage(path)
+			language, method, confidence := detectLanguage(path)
 			extension := filepath.Ext(path)
 
 			fileInfo := FileInfo{
-				Path:             path,
-				RelativePath:     relativePath,
-				Size:             info.Size(),
-				SizeFormatted:    utils.FormatBytes(info.Size()),
-				ModTime:          info.ModTime().Format(time.RFC3339),
-				ModTimeFormatted: info.ModTime().Format("2006-01-02 15:04:05"),
-				Language:         language,
-				Extension:        extension,
-				IsText:           isTextFile(path, extension),
+				Path:               path,
+				RelativePath:       relativePath,
+				Size:               info.Size(),
+				SizeFormatted:      utils.FormatBytes(info.Size()),
+				ModTime:            info.ModTime().Format(time.RFC3339),
+				ModTimeFormatted:   info.ModTime().Format("2006-01-02 15:04:05"),
+				Language:           language,
+				LanguageConfidence: confidence,
+				DetectionMethod:    method,
+				Extension:          extension,
+				IsText:             isTextFile(path, extension),
+			}
+
+			fileInfo.Category, fileInfo.CategoryReason = a.classifier.classifyPath(relativePath)
+			if categoryExcluded(fileInfo.Category, a.opts) {
+				return nil
+			}
+
+			fileInfo.IndentStyle, fileInfo.IndentSize, err = a.editorConfig.indentation(path)
+			if err != nil {
+				a.recordError(path, "editorconfig", err)
 			}
 
 			// Include content if requested and it's a text file
-			if a.opts.IncludeContent && fileInfo.IsText {
-				content, err := os.ReadFile(path)
-				if err != nil {
+			if a.opts.IncludeContent {
+				if err := loadFileContent(&fileInfo, a.opts); err != nil {
 					a.recordError(path, "read", err)
-				} else {
-					// ENHANCED: Content-based detection
-					if fileInfo.Language == "" {
-						fileInfo.Language = detectLanguageFromContent(path, content)
-					}
-					if !fileInfo.IsText && isTextContent(content) {
-						fileInfo.IsText = true
-					}
-
-					processedContent := processFileContent(string(content), fileInfo.Language, a.opts)
-					fileInfo.Content = processedContent
-					fileInfo.LineCount = utils.CountLines(processedContent)
 				}
+				if categoryExcluded(fileInfo.Category, a.opts) {
+					return nil
+				}
+			}
+
+			// Describe binary files instead of silently omitting them
+			if err := describeFile(&fileInfo, a.opts); err != nil {
+				a.recordError(path, "binary-metadata", err)
 			}
 
 			result.Files = append(result.Files, fileInfo)
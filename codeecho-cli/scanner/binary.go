@@ -0,0 +1,388 @@
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Binary file metadata
+// Why: "Binary file - content not included" tells the model nothing.
+// A MIME type, image size or archive listing says what the file actually is.
+
+const (
+	// Archive listings and import tables are capped to keep output small
+	maxBinaryEntries = 50
+	// PDFs larger than this are not scanned for a page count
+	maxPDFScanSize = 32 * 1024 * 1024
+)
+
+// BinaryMetadata describes a binary file without including its bytes
+type BinaryMetadata struct {
+	Format       string   `json:"format,omitempty"`
+	Width        int      `json:"width,omitempty"`
+	Height       int      `json:"height,omitempty"`
+	PageCount    int      `json:"page_count,omitempty"`
+	EntryCount   int      `json:"entry_count,omitempty"`
+	Entries      []string `json:"entries,omitempty"`
+	Architecture string   `json:"architecture,omitempty"`
+	Imports      []string `json:"imports,omitempty"`
+}
+
+// describeFile fills in what is known about a file besides its text
+// Every file is hashed, so every file in a pack has a SHA-256; format
+// details follow --binary-metadata
+func describeFile(fileInfo *FileInfo, opts ScanOptions) error {
+	if fileInfo.IsText {
+		// Text is hashed when its content is loaded; structure-only scans
+		// (--no-content) hash the raw bytes here
+		if fileInfo.SHA256 == "" {
+			if err := hashFile(fileInfo); err != nil {
+				return err
+			}
+		}
+		// SVG is text, but its dimensions are as useful as a PNG's
+		if opts.BinaryMetadata && strings.EqualFold(filepath.Ext(fileInfo.Path), ".svg") {
+			return loadSVGMetadata(fileInfo)
+		}
+		return nil
+	}
+	if !opts.BinaryMetadata {
+		return hashFile(fileInfo)
+	}
+	return loadBinaryMetadata(fileInfo)
+}
+
+// hashFile sets the SHA-256 of a file whose content is not read
+func hashFile(fileInfo *FileInfo) error {
+	f, err := os.Open(fileInfo.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return err
+	}
+	fileInfo.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+	return nil
+}
+
+// loadSVGMetadata records the MIME type and dimensions of a text SVG
+func loadSVGMetadata(fileInfo *FileInfo) error {
+	f, err := os.Open(fileInfo.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fileInfo.MimeType = binaryMimeType("svg", nil)
+	if width, height := svgDimensions(f); width > 0 && height > 0 {
+		fileInfo.Binary = &BinaryMetadata{Format: "svg", Width: width, Height: height}
+	}
+	return nil
+}
+
+// loadBinaryMetadata fills MIME type, hash and format details for a binary file
+func loadBinaryMetadata(fileInfo *FileInfo) error {
+	f, err := os.Open(fileInfo.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Hash the whole file while we have it open
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return err
+	}
+	fileInfo.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+
+	// Sniff the first 512 bytes (all http.DetectContentType looks at)
+	header := make([]byte, 512)
+	n, err := f.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	header = header[:n]
+
+	meta := &BinaryMetadata{Format: sniffBinaryFormat(header, fileInfo.Path)}
+	fileInfo.MimeType = binaryMimeType(meta.Format, header)
+
+	// Format-specific details are best effort - a truncated or unusual
+	// file still gets its MIME type and hash
+	switch meta.Format {
+	case "png":
+		if cfg, err := png.DecodeConfig(io.NewSectionReader(f, 0, fileInfo.Size)); err == nil {
+			meta.Width, meta.Height = cfg.Width, cfg.Height
+		}
+	case "jpeg":
+		if cfg, err := jpeg.DecodeConfig(io.NewSectionReader(f, 0, fileInfo.Size)); err == nil {
+			meta.Width, meta.Height = cfg.Width, cfg.Height
+		}
+	case "gif":
+		if cfg, err := gif.DecodeConfig(io.NewSectionReader(f, 0, fileInfo.Size)); err == nil {
+			meta.Width, meta.Height = cfg.Width, cfg.Height
+		}
+	case "svg":
+		meta.Width, meta.Height = svgDimensions(io.NewSectionReader(f, 0, fileInfo.Size))
+	case "pdf":
+		if fileInfo.Size <= maxPDFScanSize {
+			meta.PageCount = pdfPageCount(io.NewSectionReader(f, 0, fileInfo.Size))
+		}
+	case "zip", "jar":
+		if r, err := zip.NewReader(f, fileInfo.Size); err == nil {
+			for _, entry := range r.File {
+				meta.Entries = append(meta.Entries, entry.Name)
+			}
+		}
+	case "tar":
+		meta.Entries = tarEntries(io.NewSectionReader(f, 0, fileInfo.Size))
+	case "tar.gz":
+		if gz, err := gzip.NewReader(io.NewSectionReader(f, 0, fileInfo.Size)); err == nil {
+			meta.Entries = tarEntries(gz)
+			gz.Close()
+		}
+	case "elf":
+		if ef, err := elf.NewFile(f); err == nil {
+			meta.Architecture = strings.ToLower(strings.TrimPrefix(ef.Machine.String(), "EM_"))
+			meta.Imports, _ = ef.ImportedLibraries()
+			ef.Close()
+		}
+	case "macho":
+		if mf, err := macho.NewFile(f); err == nil {
+			meta.Architecture = strings.ToLower(strings.TrimPrefix(mf.Cpu.String(), "Cpu"))
+			meta.Imports, _ = mf.ImportedLibraries()
+			mf.Close()
+		}
+	case "pe":
+		if pf, err := pe.NewFile(f); err == nil {
+			meta.Architecture = peMachineName(pf.Machine)
+			meta.Imports, _ = pf.ImportedLibraries()
+			pf.Close()
+		}
+	}
+
+	meta.EntryCount = len(meta.Entries)
+	if len(meta.Entries) > maxBinaryEntries {
+		meta.Entries = meta.Entries[:maxBinaryEntries]
+	}
+	sort.Strings(meta.Imports)
+	if len(meta.Imports) > maxBinaryEntries {
+		meta.Imports = meta.Imports[:maxBinaryEntries]
+	}
+
+	fileInfo.Binary = meta
+	return nil
+}
+
+// sniffBinaryFormat identifies a file from its magic bytes
+// The extension is only used to refine otherwise identical containers (zip vs jar)
+func sniffBinaryFormat(header []byte, path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	switch {
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg"
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return "gif"
+	case bytes.HasPrefix(header, []byte("%PDF-")):
+		return "pdf"
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		if ext == ".jar" || ext == ".war" {
+			return "jar"
+		}
+		return "zip"
+	case bytes.HasPrefix(header, []byte{0x1F, 0x8B}):
+		if ext == ".tgz" || strings.HasSuffix(strings.ToLower(path), ".tar.gz") {
+			return "tar.gz"
+		}
+		return "gzip"
+	case len(header) > 262 && string(header[257:262]) == "ustar":
+		return "tar"
+	case bytes.HasPrefix(header, []byte("\x7FELF")):
+		return "elf"
+	case isMachOMagic(header):
+		return "macho"
+	case bytes.HasPrefix(header, []byte("MZ")):
+		return "pe"
+	case ext == ".svg" || bytes.Contains(bytes.ToLower(header), []byte("<svg")):
+		return "svg"
+	}
+
+	return ""
+}
+
+func isMachOMagic(header []byte) bool {
+	if len(header) < 4 {
+		return false
+	}
+	switch string(header[:4]) {
+	case "\xFE\xED\xFA\xCE", "\xFE\xED\xFA\xCF", "\xCE\xFA\xED\xFE", "\xCF\xFA\xED\xFE":
+		return true
+	}
+	return false
+}
+
+// binaryMimeType maps a sniffed format to a MIME type
+// Falls back to the standard library's content sniffing
+func binaryMimeType(format string, header []byte) string {
+	switch format {
+	case "svg":
+		return "image/svg+xml"
+	case "jar":
+		return "application/java-archive"
+	case "tar":
+		return "application/x-tar"
+	case "tar.gz", "gzip":
+		return "application/gzip"
+	case "elf":
+		return "application/x-executable"
+	case "macho":
+		return "application/x-mach-binary"
+	case "pe":
+		return "application/vnd.microsoft.portable-executable"
+	}
+
+	mimeType := http.DetectContentType(header)
+	if idx := strings.Index(mimeType, ";"); idx > 0 {
+		mimeType = mimeType[:idx]
+	}
+	return mimeType
+}
+
+// svgDimensions reads width/height (or the viewBox) from the root <svg> element
+func svgDimensions(r io.Reader) (int, int) {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, 0
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "svg" {
+			continue
+		}
+
+		var width, height int
+		var viewBox string
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "width":
+				width = parseSVGLength(attr.Value)
+			case "height":
+				height = parseSVGLength(attr.Value)
+			case "viewBox":
+				viewBox = attr.Value
+			}
+		}
+
+		if (width == 0 || height == 0) && viewBox != "" {
+			fields := strings.Fields(strings.ReplaceAll(viewBox, ",", " "))
+			if len(fields) == 4 {
+				width = parseSVGLength(fields[2])
+				height = parseSVGLength(fields[3])
+			}
+		}
+		return width, height
+	}
+}
+
+var svgLengthPattern = regexp.MustCompile(`^\s*([0-9.]+)\s*(px)?\s*$`)
+
+func parseSVGLength(value string) int {
+	// Relative units (%, em) have no intrinsic pixel size
+	match := svgLengthPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0
+	}
+	f, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0
+	}
+	return int(f + 0.5)
+}
+
+var (
+	pdfPagesCountPattern = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+	pdfPagePattern       = regexp.MustCompile(`/Type\s*/Page\b`)
+)
+
+// pdfPageCount estimates the number of pages in a PDF
+// Why: The root /Pages node carries the total; counting /Page objects is the
+// fallback for files whose page tree lives in compressed object streams
+func pdfPageCount(r io.Reader) int {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0
+	}
+
+	maxCount := 0
+	for _, match := range pdfPagesCountPattern.FindAllSubmatch(data, -1) {
+		value := match[1]
+		if len(value) == 0 {
+			value = match[2]
+		}
+		if n, err := strconv.Atoi(string(value)); err == nil && n > maxCount {
+			maxCount = n
+		}
+	}
+	if maxCount > 0 {
+		return maxCount
+	}
+
+	return len(pdfPagePattern.FindAll(data, -1))
+}
+
+// tarEntries lists the file names in a tar stream
+func tarEntries(r io.Reader) []string {
+	var entries []string
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		entries = append(entries, header.Name)
+	}
+	return entries
+}
+
+func peMachineName(machine uint16) string {
+	switch machine {
+	case pe.IMAGE_FILE_MACHINE_I386:
+		return "i386"
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return "amd64"
+	case pe.IMAGE_FILE_MACHINE_ARM:
+		return "arm"
+	case pe.IMAGE_FILE_MACHINE_ARMNT:
+		return "armnt"
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		return "arm64"
+	}
+	return fmt.Sprintf("0x%04x", machine)
+}
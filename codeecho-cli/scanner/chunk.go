@@ -0,0 +1,522 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
+)
+
+// Chunking for retrieval
+// Why: Embedding models work best on passages of a few hundred tokens, and
+// a passage that stops mid-function retrieves badly. Files are split at
+// declarations, headings or blank lines and packed up to a token budget.
+
+// Chunk is one retrievable slice of a file
+type Chunk struct {
+	ID        string `json:"id"`
+	Path      string `json:"path"`
+	Language  string `json:"language,omitempty"`
+	Index     int    `json:"chunk_index"`
+	Count     int    `json:"chunk_count"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Symbol    string `json:"symbol,omitempty"`
+	Tokens    int    `json:"tokens"`
+	Content   string `json:"content,omitempty"`
+}
+
+// Defaults for --chunk-tokens and --overlap
+const (
+	DefaultChunkTokens  = 512
+	DefaultChunkOverlap = 64
+)
+
+// Declarations that start a new segment, by language
+// Every non-empty capture group is part of the symbol name, joined with "."
+// (Go methods capture the receiver type as well as the name)
+var symbolPatterns = map[string]*regexp.Regexp{
+	"go":         regexp.MustCompile(`^func\s+(?:\(\s*\w*\s*\*?(\w+)[^)]*\)\s*)?(\w+)|^type\s+(\w+)`),
+	"python":     regexp.MustCompile(`^\s*(?:async\s+)?(?:def|class)\s+(\w+)`),
+	"javascript": regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\*?\s+(\w+)|^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)|^(?:export\s+)?(?:const|let|var)\s+(\w+)\s*=\s*(?:async\s+)?(?:function|\([^)]*\)\s*=>|\w+\s*=>)`),
+	"typescript": regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?function\*?\s+(\w+)|^\s*(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:class|interface|enum|namespace)\s+(\w+)|^(?:export\s+)?type\s+(\w+)\s*(?:<[^=]*>)?\s*=|^(?:export\s+)?(?:const|let|var)\s+(\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function|\([^)]*\)\s*(?::[^=]+)?=>|\w+\s*=>)`),
+	"java":       regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|final|abstract|sealed|non-sealed|strictfp)\s+)*(?:class|interface|enum|record|@interface)\s+(\w+)|^\s+(?:(?:public|private|protected|static|final|abstract|synchronized|native|default)\s+)+[\w<>\[\],.? ]+\s+(\w+)\s*\(`),
+	"csharp":     regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|sealed|abstract|partial|readonly)\s+)*(?:class|interface|enum|struct|record|namespace)\s+([\w.]+)|^\s+(?:(?:public|private|protected|internal|static|virtual|override|abstract|async|sealed)\s+)+[\w<>\[\],.? ]+\s+(\w+)\s*\(`),
+	"kotlin":     regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|open|abstract|sealed|data|inline|suspend|override|enum|annotation)\s+)*(?:class|interface|object|fun)\s+(?:<[^>]*>\s*)?(?:\w+\.)?(\w+)`),
+	"scala":      regexp.MustCompile(`^\s*(?:(?:private|protected|override|final|sealed|abstract|implicit|case|lazy)\s+)*(?:class|trait|object|def|enum)\s+(\w+)`),
+	"swift":      regexp.MustCompile(`^\s*(?:(?:public|private|fileprivate|internal|open|static|final|override|mutating)\s+)*(?:func|class|struct|enum|protocol|extension|actor)\s+(\w+)`),
+	"rust":       regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:(?:async|const|unsafe|extern\s+"\w+")\s+)*(?:fn|struct|enum|trait|mod|union)\s+(\w+)|^\s*impl(?:<[^>]*>)?\s+(?:[\w:<>, ]+\s+for\s+)?(\w+)`),
+	"c":          regexp.MustCompile(`^(?:struct|union|enum)\s+(\w+)\s*\{|^[A-Za-z_][\w \t\*]*?\b(\w+)\s*\([^;]*$`),
+	"cpp":        regexp.MustCompile(`^\s*(?:template\s*<[^>]*>\s*)?(?:class|struct|union|namespace)\s+(\w+)[^;]*$|^[A-Za-z_][\w \t\*&:<>,~]*?\b([\w:~]+)\s*\([^;]*$`),
+	"ruby":       regexp.MustCompile(`^\s*(?:def|class|module)\s+(?:self\.)?([\w:?!]+)`),
+	"php":        regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|abstract|final|readonly)\s+)*(?:function|class|interface|trait|enum)\s+(\w+)`),
+	"shell":      regexp.MustCompile(`^\s*function\s+([\w:.-]+)|^\s*([\w:.-]+)\s*\(\)\s*\{?`),
+	"lua":        regexp.MustCompile(`^\s*(?:local\s+)?function\s+([\w.:]+)`),
+	"elixir":     regexp.MustCompile(`^\s*(?:defmodule|defp?|defmacrop?|defprotocol|defimpl)\s+([\w.?!]+)`),
+	"dart":       regexp.MustCompile(`^\s*(?:abstract\s+)?(?:class|mixin|extension|enum)\s+(\w+)|^\s*(?:static\s+)?(?:Future<[^>]*>|[\w<>?]+)\s+(\w+)\s*\([^;]*$`),
+	"haskell":    regexp.MustCompile(`^(?:data|newtype|class|instance)\s+(\w+)|^(\w+)\s*::`),
+}
+
+// Languages that share another language's declaration syntax
+var symbolPatternAliases = map[string]string{
+	"tsx":         "typescript",
+	"jsx":         "javascript",
+	"objective-c": "c",
+	"cuda":        "cpp",
+	"zsh":         "shell",
+	"bash":        "shell",
+}
+
+var markdownHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+
+// segment is a run of lines that should stay together when possible
+type segment struct {
+	start  int // first line index (0-based)
+	end    int // one past the last line
+	symbol string
+}
+
+// ChunkFile splits a text file's content into chunks of at most maxTokens
+// Each chunk after the first repeats up to overlap tokens of the one before
+// so a passage cut at a boundary is still retrievable from either side
+func ChunkFile(file *FileInfo, maxTokens, overlap int) []Chunk {
+	if !file.IsText || file.Content == "" {
+		return nil
+	}
+	if maxTokens <= 0 {
+		maxTokens = DefaultChunkTokens
+	}
+	if overlap < 0 || overlap >= maxTokens {
+		overlap = 0
+	}
+
+	lines := strings.SplitAfter(file.Content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	// New content per chunk leaves room for the overlap carried in
+	budget := maxTokens - overlap
+
+	// Why: A minified or generated line can hold more than a whole chunk.
+	// Chunks are packed from units: a line, or a piece of a line too long
+	// to fit the budget on its own.
+	var units []string
+	var unitLine []int
+	firstUnit := make([]int, len(lines)+1)
+	for i, line := range lines {
+		firstUnit[i] = len(units)
+		for _, piece := range splitLongLine(line, budget) {
+			units = append(units, piece)
+			unitLine = append(unitLine, i)
+		}
+	}
+	firstUnit[len(lines)] = len(units)
+
+	unitTokens := make([]int, len(units))
+	for i, unit := range units {
+		unitTokens[i] = utils.EstimateTokens(unit)
+	}
+
+	segments := splitSegments(lines, file.Language)
+	for i := range segments {
+		segments[i].start = firstUnit[segments[i].start]
+		segments[i].end = firstUnit[segments[i].end]
+	}
+	ranges := packSegments(segments, units, unitTokens, budget)
+	ranges = mergeBlankRanges(ranges, units, unitTokens, budget)
+
+	chunks := make([]Chunk, 0, len(ranges))
+	seen := make(map[string]int)
+	for i, r := range ranges {
+		start := r.start
+		if i > 0 {
+			start = overlapStart(unitTokens, ranges[i-1].start, r.start, overlap)
+		}
+
+		content := strings.Join(units[start:r.end], "")
+		tokens := 0
+		for _, t := range unitTokens[start:r.end] {
+			tokens += t
+		}
+
+		chunks = append(chunks, Chunk{
+			ID:        chunkID(file.RelativePath, content, seen),
+			Path:      file.RelativePath,
+			Language:  file.Language,
+			Index:     i,
+			Count:     len(ranges),
+			StartLine: unitLine[start] + 1,
+			EndLine:   unitLine[r.end-1] + 1,
+			Symbol:    r.symbol,
+			Tokens:    tokens,
+			Content:   content,
+		})
+	}
+
+	return chunks
+}
+
+// chunkID hashes path and content so IDs survive edits elsewhere in the file
+// Identical chunks in one file get a numeric suffix to stay unique
+func chunkID(path, content string, seen map[string]int) string {
+	sum := sha256.Sum256([]byte(path + "\x00" + content))
+	id := hex.EncodeToString(sum[:8])
+	seen[id]++
+	if n := seen[id]; n > 1 {
+		id = fmt.Sprintf("%s-%d", id, n)
+	}
+	return id
+}
+
+// splitSegments finds natural boundaries: declarations for languages with a
+// symbol pattern, headings for Markdown, blank-line blocks for everything else
+func splitSegments(lines []string, language string) []segment {
+	if language == "markdown" || language == "rmarkdown" || language == "mdx" {
+		return headingSegments(lines)
+	}
+
+	if pattern := symbolPattern(language); pattern != nil {
+		if segments := declarationSegments(lines, language, pattern); len(segments) > 1 {
+			return segments
+		}
+	}
+
+	return blankLineSegments(lines)
+}
+
+func symbolPattern(language string) *regexp.Regexp {
+	if pattern := symbolPatterns[language]; pattern != nil {
+		return pattern
+	}
+	return symbolPatterns[symbolPatternAliases[language]]
+}
+
+// Symbol is a declaration found in a file
+type Symbol struct {
+	Name string `json:"name"` // Qualified by nesting, e.g. Class.method
+	Line int    `json:"line"` // 1-based
+}
+
+// FindSymbols lists the functions, types and classes declared in content
+// Languages without a symbol pattern return nil
+func FindSymbols(content, language string) []Symbol {
+	pattern := symbolPattern(language)
+	if pattern == nil {
+		return nil
+	}
+	return findDeclarations(strings.Split(content, "\n"), pattern)
+}
+
+// findDeclarations matches pattern on every line
+// Nesting is read from indentation, so a method is named Class.method
+func findDeclarations(lines []string, pattern *regexp.Regexp) []Symbol {
+	var symbols []Symbol
+	var stack []scopeEntry
+
+	for i, line := range lines {
+		match := pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		var parts []string
+		for _, group := range match[1:] {
+			if group != "" {
+				parts = append(parts, group)
+			}
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		var name string
+		depth := len(line) - len(strings.TrimLeft(line, " \t"))
+		stack, name = qualify(stack, depth, strings.Join(parts, "."), ".")
+		symbols = append(symbols, Symbol{Name: name, Line: i + 1})
+	}
+
+	return symbols
+}
+
+// scopeEntry tracks an open declaration or heading for qualified names
+type scopeEntry struct {
+	depth int
+	name  string
+}
+
+// qualify pops scopes at depth or deeper, pushes name and returns the path
+func qualify(stack []scopeEntry, depth int, name, sep string) ([]scopeEntry, string) {
+	for len(stack) > 0 && stack[len(stack)-1].depth >= depth {
+		stack = stack[:len(stack)-1]
+	}
+	stack = append(stack, scopeEntry{depth: depth, name: name})
+
+	names := make([]string, len(stack))
+	for i, entry := range stack {
+		names[i] = entry.name
+	}
+	return stack, strings.Join(names, sep)
+}
+
+// declarationSegments starts a segment at each declaration, pulling in the
+// doc comments and annotations directly above it
+func declarationSegments(lines []string, language string, pattern *regexp.Regexp) []segment {
+	prefixes := []string{"@", "#[", "/*", "*"}
+	if lang, ok := LookupLanguage(language); ok {
+		prefixes = append(prefixes, lang.LineComments...)
+	}
+
+	var segments []segment
+	current := segment{}
+
+	for _, symbol := range findDeclarations(lines, pattern) {
+		start := symbol.Line - 1
+		for start > current.start && hasAnyPrefix(strings.TrimSpace(lines[start-1]), prefixes) {
+			start--
+		}
+		if start > current.start {
+			current.end = start
+			segments = append(segments, current)
+			current = segment{start: start}
+		}
+		current.symbol = symbol.Name
+	}
+
+	current.end = len(lines)
+	return append(segments, current)
+}
+
+// headingSegments starts a segment at each Markdown heading outside code
+// fences; the symbol is the heading path, e.g. "Install > Linux"
+func headingSegments(lines []string) []segment {
+	var segments []segment
+	var stack []scopeEntry
+	current := segment{}
+	inFence := false
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		match := markdownHeadingPattern.FindStringSubmatch(trimmed)
+		if match == nil {
+			continue
+		}
+
+		if i > current.start {
+			current.end = i
+			segments = append(segments, current)
+			current = segment{start: i}
+		}
+		stack, current.symbol = qualify(stack, len(match[1]), match[2], " > ")
+	}
+
+	current.end = len(lines)
+	return append(segments, current)
+}
+
+// blankLineSegments splits on blank lines, keeping each blank with the block
+// before it
+func blankLineSegments(lines []string) []segment {
+	var segments []segment
+	current := segment{}
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i-1]) == "" && strings.TrimSpace(lines[i]) != "" {
+			current.end = i
+			segments = append(segments, current)
+			current = segment{start: i}
+		}
+	}
+
+	current.end = len(lines)
+	return append(segments, current)
+}
+
+// packSegments merges consecutive segments up to budget tokens and splits
+// segments that are too large on their own
+// A chunk keeps the symbol of the segment it starts in
+func packSegments(segments []segment, units []string, unitTokens []int, budget int) []segment {
+	var ranges []segment
+	var current *segment
+	currentTokens := 0
+
+	for _, seg := range segments {
+		tokens := 0
+		for _, t := range unitTokens[seg.start:seg.end] {
+			tokens += t
+		}
+
+		if current != nil && currentTokens+tokens <= budget {
+			current.end = seg.end
+			currentTokens += tokens
+			continue
+		}
+
+		if tokens <= budget {
+			ranges = append(ranges, seg)
+			current = &ranges[len(ranges)-1]
+			currentTokens = tokens
+			continue
+		}
+
+		// Oversized segment: cut at unit boundaries
+		ranges = append(ranges, splitSegment(seg, units, unitTokens, budget)...)
+		current = nil
+		currentTokens = 0
+	}
+
+	return ranges
+}
+
+// splitSegment cuts a segment into pieces of at most budget tokens,
+// preferring to cut after a blank line
+func splitSegment(seg segment, units []string, unitTokens []int, budget int) []segment {
+	var pieces []segment
+	start, tokens, lastBlank := seg.start, 0, -1
+
+	for i := seg.start; i < seg.end; i++ {
+		if tokens+unitTokens[i] > budget && i > start {
+			cut := i
+			if lastBlank > start {
+				cut = lastBlank
+			}
+			pieces = append(pieces, segment{start: start, end: cut, symbol: seg.symbol})
+			start, tokens, lastBlank = cut, 0, -1
+			for j := cut; j < i; j++ {
+				tokens += unitTokens[j]
+			}
+		}
+		tokens += unitTokens[i]
+		if strings.TrimSpace(units[i]) == "" && strings.HasSuffix(units[i], "\n") {
+			lastBlank = i + 1
+		}
+	}
+
+	return append(pieces, segment{start: start, end: seg.end, symbol: seg.symbol})
+}
+
+// mergeBlankRanges folds a range of only blank lines into the range before
+// or after it, if either has room
+// Why: Cutting a segment just before its trailing blank line would otherwise
+// leave a chunk that is a lone "\n"
+func mergeBlankRanges(ranges []segment, units []string, unitTokens []int, budget int) []segment {
+	tokensIn := func(r segment) int {
+		tokens := 0
+		for _, t := range unitTokens[r.start:r.end] {
+			tokens += t
+		}
+		return tokens
+	}
+
+	var merged []segment
+	for i := 0; i < len(ranges); i++ {
+		r := ranges[i]
+		if strings.TrimSpace(strings.Join(units[r.start:r.end], "")) != "" || len(ranges) == 1 {
+			merged = append(merged, r)
+			continue
+		}
+
+		if n := len(merged); n > 0 && tokensIn(merged[n-1])+tokensIn(r) <= budget {
+			merged[n-1].end = r.end
+		} else if i+1 < len(ranges) && tokensIn(ranges[i+1])+tokensIn(r) <= budget {
+			ranges[i+1].start = r.start
+		} else {
+			merged = append(merged, r)
+		}
+	}
+
+	return merged
+}
+
+// splitLongLine cuts a line of more than budget tokens into pieces that fit,
+// preferring to cut after a space so words stay whole
+func splitLongLine(line string, budget int) []string {
+	var pieces []string
+	for {
+		cut := fitPrefix(line, budget)
+		if cut == len(line) {
+			return append(pieces, line)
+		}
+		if space := strings.LastIndexByte(line[:cut], ' '); space >= cut/2 {
+			cut = space + 1
+		}
+		pieces = append(pieces, line[:cut])
+		line = line[cut:]
+	}
+}
+
+// fitPrefix returns the length of the longest prefix of s, cut between
+// runes, that estimates at most budget tokens (at least one rune)
+func fitPrefix(s string, budget int) int {
+	fits := func(n int) bool { return utils.EstimateTokens(s[:n]) <= budget }
+	if fits(len(s)) {
+		return len(s)
+	}
+
+	// Gallop to bracket the cut so a long line is not re-estimated whole
+	// on every step, then binary search; lo always fits, hi never does
+	lo, hi := 0, len(s)
+	for n := 4 * budget; n < len(s); n *= 2 {
+		for n < len(s) && !utf8.RuneStart(s[n]) {
+			n++
+		}
+		if n == len(s) || !fits(n) {
+			hi = n
+			break
+		}
+		lo = n
+	}
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		for mid < hi && !utf8.RuneStart(s[mid]) {
+			mid++
+		}
+		if mid == hi {
+			break
+		}
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	if lo == 0 {
+		_, size := utf8.DecodeRuneInString(s)
+		return size
+	}
+	return lo
+}
+
+// overlapStart walks back from start while the carried units fit in overlap,
+// never past the start of the previous chunk
+func overlapStart(unitTokens []int, previousStart, start, overlap int) int {
+	tokens := 0
+	for start > previousStart+1 && tokens+unitTokens[start-1] <= overlap {
+		start--
+		tokens += unitTokens[start]
+	}
+	return start
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	if s == "" {
+		return false
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
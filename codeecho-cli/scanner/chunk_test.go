@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// checkChunks fails if a chunk breaks the token limit or is blank
+func checkChunks(t *testing.T, file *FileInfo, maxTokens, overlap int) []Chunk {
+	t.Helper()
+
+	chunks := ChunkFile(file, maxTokens, overlap)
+	if len(chunks) == 0 {
+		t.Fatal("no chunks")
+	}
+	for _, chunk := range chunks {
+		if chunk.Tokens > maxTokens {
+			t.Errorf("chunk %d has %d tokens, limit %d", chunk.Index, chunk.Tokens, maxTokens)
+		}
+		if strings.TrimSpace(chunk.Content) == "" {
+			t.Errorf("chunk %d is blank: %q", chunk.Index, chunk.Content)
+		}
+	}
+	return chunks
+}
+
+func TestChunkFileSplitsLongLines(t *testing.T) {
+	var items []string
+	for i := 0; i < 400; i++ {
+		items = append(items, fmt.Sprintf("%q", fmt.Sprintf("item%d", i)))
+	}
+	content := "// data\nconst data = [" + strings.Join(items, ", ") + "];\n"
+	file := &FileInfo{RelativePath: "data.js", Language: "javascript", Content: content, IsText: true}
+
+	chunks := checkChunks(t, file, 128, 0)
+	if len(chunks) < 2 {
+		t.Fatalf("long line was not split: %d chunks", len(chunks))
+	}
+
+	var joined strings.Builder
+	for _, chunk := range chunks {
+		joined.WriteString(chunk.Content)
+		if chunk.EndLine > 2 || chunk.StartLine > chunk.EndLine {
+			t.Errorf("chunk %d has lines %d-%d", chunk.Index, chunk.StartLine, chunk.EndLine)
+		}
+	}
+	if joined.String() != content {
+		t.Error("chunks without overlap do not add back up to the file")
+	}
+	if last := chunks[len(chunks)-1]; last.EndLine != 2 {
+		t.Errorf("last chunk ends on line %d, want 2", last.EndLine)
+	}
+}
+
+func TestChunkFileNoBlankChunks(t *testing.T) {
+	var words []string
+	for i := 0; i < 60; i++ {
+		words = append(words, fmt.Sprintf("word%d lorem ipsum dolor sit amet.", i))
+	}
+	content := "# Title\n\nIntro.\n\n## Section\n\n" + strings.Join(words, " ") + "\n\n## Next\n\nshort\n"
+	file := &FileInfo{RelativePath: "doc.md", Language: "markdown", Content: content, IsText: true}
+
+	checkChunks(t, file, 128, 64)
+	checkChunks(t, file, 128, 0)
+}
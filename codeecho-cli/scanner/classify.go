@@ -0,0 +1,320 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// Generated, minified and vendored file detection
+// Why: Protobuf stubs, lockfiles and copied-in libraries crowd out the code
+// people actually wrote. Classify them so they can be labelled or dropped.
+
+// File categories recorded in FileInfo.Category
+const (
+	CategorySource    = "source"
+	CategoryGenerated = "generated"
+	CategoryVendored  = "vendored"
+)
+
+// Reasons recorded in FileInfo.CategoryReason
+const (
+	ReasonGitAttributes   = "gitattributes"
+	ReasonGeneratedHeader = "generated-header"
+	ReasonGeneratedName   = "generated-filename"
+	ReasonLockfile        = "lockfile"
+	ReasonMinified        = "minified"
+	ReasonVendoredPath    = "vendored-path"
+	ReasonVendoredLibrary = "vendored-library"
+)
+
+// Minification thresholds (linguist uses a 110 char average line length)
+const (
+	minifiedAvgLineLength   = 110
+	minifiedWhitespaceRatio = 0.05
+	minifiedMinSize         = 2048
+)
+
+var lockfileNames = map[string]bool{
+	"package-lock.json": true, "npm-shrinkwrap.json": true, "yarn.lock": true,
+	"pnpm-lock.yaml": true, "bun.lockb": true, "composer.lock": true,
+	"gemfile.lock": true, "cargo.lock": true, "go.sum": true, "go.work.sum": true,
+	"poetry.lock": true, "pipfile.lock": true, "uv.lock": true, "pdm.lock": true,
+	"podfile.lock": true, "mix.lock": true, "flake.lock": true,
+	"packages.lock.json": true, "gradle.lockfile": true, "pubspec.lock": true,
+	"package.resolved": true,
+}
+
+var generatedNamePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\.pb\.(go|cc|h|c|swift|dart)$`),
+	regexp.MustCompile(`\.pb\.gw\.go$`),
+	regexp.MustCompile(`_pb2(_grpc)?\.pyi?$`),
+	regexp.MustCompile(`_grpc\.pb\.go$`),
+	regexp.MustCompile(`(^|/)zz_generated[^/]*\.go$`),
+	regexp.MustCompile(`_generated\.go$`),
+	regexp.MustCompile(`(^|/)bindata\.go$`),
+	regexp.MustCompile(`\.designer\.(cs|vb)$`),
+	regexp.MustCompile(`\.g\.(cs|dart)$`),
+	regexp.MustCompile(`\.freezed\.dart$`),
+	regexp.MustCompile(`\.(js|css)\.map$`),
+}
+
+// Directories that only ever hold third-party code
+// Why: Names like external/ or deps/ are as often first-party code; mark
+// those with linguist-vendored in .gitattributes
+var vendoredPathPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(^|/)(vendor|third[_-]?party|3rdparty)/`),
+	regexp.MustCompile(`(^|/)(node_modules|bower_components|jspm_packages)/`),
+	regexp.MustCompile(`(^|/)(Pods|Carthage)/`),
+	regexp.MustCompile(`(^|/)\.yarn/(releases|plugins|sdks)/`),
+}
+
+// Well-known libraries that get copied into repos
+var vendoredLibraryPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(^|/)jquery([.-][\d.]+)?(\.min)?\.js$`),
+	regexp.MustCompile(`(^|/)jquery-ui([.-][\d.]+)?(\.min)?\.(js|css)$`),
+	regexp.MustCompile(`(^|/)bootstrap([.-][\d.]+)?(\.bundle)?(\.min)?\.(js|css)$`),
+	regexp.MustCompile(`(^|/)(d3|lodash|underscore|backbone|moment|angular|react|react-dom|vue|chart)([.-][\d.]+)?(\.min)?\.js$`),
+	regexp.MustCompile(`(^|/)normalize(\.min)?\.css$`),
+	regexp.MustCompile(`(^|/)font-?awesome(\.min)?\.css$`),
+}
+
+// Headers written by code generators (checked in the first few lines)
+// Why: Only markers generators actually write; a loose "auto-generated"
+// or "DO NOT EDIT" also matches hand-written comments and strings
+var generatedHeaderPatterns = []*regexp.Regexp{
+	// Go convention (go.dev/s/generatedcode), the whole line
+	regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`),
+	// Meta/Phabricator convention, used by Relay, Hack and others
+	regexp.MustCompile(`@generated\b`),
+	// protoc for languages other than Go
+	regexp.MustCompile(`(?i)generated by the protocol buffer compiler`),
+	// .NET tools
+	regexp.MustCompile(`<auto-generated\b`),
+}
+
+// Lines that start a comment in the common languages
+// A generator header is always a comment, so markers elsewhere (string
+// literals, code) are ignored
+var commentLinePattern = regexp.MustCompile(`^(//|/\*|\*|#|--|;|%|<!--|\{\{/\*)`)
+
+// Only the start of a file is searched for a generator header
+const generatedHeaderLines = 10
+
+// gitAttributeRule is one .gitattributes line relevant to classification
+type gitAttributeRule struct {
+	matcher   *ignore.GitIgnore
+	generated *bool
+	vendored  *bool
+}
+
+// fileClassifier applies .gitattributes overrides and path heuristics
+type fileClassifier struct {
+	rules []gitAttributeRule
+}
+
+// newFileClassifier loads linguist attributes from the repository root
+func newFileClassifier(rootPath string) (*fileClassifier, error) {
+	classifier := &fileClassifier{}
+
+	attrPath := filepath.Join(rootPath, ".gitattributes")
+	f, err := os.Open(attrPath)
+	if os.IsNotExist(err) {
+		// Not having .gitattributes is not an error
+		return classifier, nil
+	}
+	if err != nil {
+		return classifier, fmt.Errorf("failed to read .gitattributes: %w", err)
+	}
+	defer f.Close()
+
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		if rule, ok := parseGitAttributeLine(lines.Text()); ok {
+			classifier.rules = append(classifier.rules, rule)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return classifier, fmt.Errorf("failed to parse .gitattributes: %w", err)
+	}
+
+	return classifier, nil
+}
+
+// parseGitAttributeLine extracts linguist-generated / linguist-vendored settings
+// Supports "attr", "attr=true", "attr=false", "-attr" and "!attr"
+func parseGitAttributeLine(line string) (gitAttributeRule, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return gitAttributeRule{}, false
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return gitAttributeRule{}, false
+	}
+
+	rule := gitAttributeRule{}
+	for _, attr := range fields[1:] {
+		value := true
+		switch {
+		case strings.HasPrefix(attr, "-"), strings.HasPrefix(attr, "!"):
+			value = false
+			attr = attr[1:]
+		case strings.HasSuffix(attr, "=false"):
+			value = false
+			attr = strings.TrimSuffix(attr, "=false")
+		case strings.HasSuffix(attr, "=true"):
+			attr = strings.TrimSuffix(attr, "=true")
+		}
+
+		v := value
+		switch attr {
+		case "linguist-generated":
+			rule.generated = &v
+		case "linguist-vendored":
+			rule.vendored = &v
+		}
+	}
+
+	if rule.generated == nil && rule.vendored == nil {
+		return gitAttributeRule{}, false
+	}
+
+	rule.matcher = ignore.CompileIgnoreLines(fields[0])
+	return rule, true
+}
+
+// classifyPath categorizes a file from its path alone
+// Why: Path-only classification lets excluded files skip the read entirely
+func (c *fileClassifier) classifyPath(relativePath string) (string, string) {
+	slashPath := filepath.ToSlash(relativePath)
+
+	// .gitattributes is explicit, so it wins; later lines override earlier ones
+	var generated, vendored *bool
+	if c != nil {
+		for _, rule := range c.rules {
+			if !rule.matcher.MatchesPath(slashPath) {
+				continue
+			}
+			if rule.generated != nil {
+				generated = rule.generated
+			}
+			if rule.vendored != nil {
+				vendored = rule.vendored
+			}
+		}
+	}
+	switch {
+	case vendored != nil && *vendored:
+		return CategoryVendored, ReasonGitAttributes
+	case generated != nil && *generated:
+		return CategoryGenerated, ReasonGitAttributes
+	case vendored != nil || generated != nil:
+		// Explicitly marked as not generated/vendored
+		return CategorySource, ReasonGitAttributes
+	}
+
+	if lockfileNames[strings.ToLower(path.Base(slashPath))] {
+		return CategoryGenerated, ReasonLockfile
+	}
+	for _, pattern := range generatedNamePatterns {
+		if pattern.MatchString(slashPath) {
+			return CategoryGenerated, ReasonGeneratedName
+		}
+	}
+	for _, pattern := range vendoredPathPatterns {
+		if pattern.MatchString(slashPath) {
+			return CategoryVendored, ReasonVendoredPath
+		}
+	}
+	for _, pattern := range vendoredLibraryPatterns {
+		if pattern.MatchString(slashPath) {
+			return CategoryVendored, ReasonVendoredLibrary
+		}
+	}
+	if isMinifiedName(slashPath) {
+		return CategoryGenerated, ReasonMinified
+	}
+
+	return CategorySource, ""
+}
+
+// classifyContent refines a path-only "source" classification using content
+func classifyContent(filePath, content string) (string, string) {
+	if hasGeneratedHeader(content) {
+		return CategoryGenerated, ReasonGeneratedHeader
+	}
+	if isMinifiedContent(filePath, content) {
+		return CategoryGenerated, ReasonMinified
+	}
+	return CategorySource, ""
+}
+
+func isMinifiedName(slashPath string) bool {
+	base := strings.ToLower(path.Base(slashPath))
+	return strings.Contains(base, ".min.") || strings.HasSuffix(base, "-min.js")
+}
+
+func hasGeneratedHeader(content string) bool {
+	lines := strings.SplitN(content, "\n", generatedHeaderLines+1)
+	if len(lines) > generatedHeaderLines {
+		lines = lines[:generatedHeaderLines]
+	}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if !commentLinePattern.MatchString(line) {
+			continue
+		}
+		for _, pattern := range generatedHeaderPatterns {
+			if pattern.MatchString(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isMinifiedContent spots minified JS/CSS from line length and whitespace
+func isMinifiedContent(filePath, content string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".js", ".mjs", ".cjs", ".css":
+	default:
+		return false
+	}
+
+	if len(content) < minifiedMinSize {
+		return false
+	}
+
+	lines := strings.Count(content, "\n") + 1
+	if len(content)/lines > minifiedAvgLineLength {
+		return true
+	}
+
+	whitespace := 0
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case ' ', '\t', '\n', '\r':
+			whitespace++
+		}
+	}
+	return float64(whitespace)/float64(len(content)) < minifiedWhitespaceRatio
+}
+
+// categoryExcluded reports whether options ask to drop this category
+func categoryExcluded(category string, opts ScanOptions) bool {
+	switch category {
+	case CategoryGenerated:
+		return opts.ExcludeGenerated
+	case CategoryVendored:
+		return opts.ExcludeVendored
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import "testing"
+
+func TestHasGeneratedHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"go header", "// Code generated by stringer; DO NOT EDIT.\n\npackage a\n", true},
+		{"go header with CRLF", "// Code generated by protoc-gen-go. DO NOT EDIT.\r\npackage a\r\n", true},
+		{"@generated", "/**\n * @generated SignedSource<<abc>>\n */\n", true},
+		{"protoc python", "# -*- coding: utf-8 -*-\n# Generated by the protocol buffer compiler.  DO NOT EDIT!\n", true},
+		{"dotnet", "//------\n// <auto-generated>\n//     This code was generated by a tool.\n", true},
+		{"prose in a comment", "package b\n\n// we describe auto-generated docs here\n", false},
+		{"do not edit in a comment", "# DO NOT EDIT without telling the team\n", false},
+		{"marker in a string", "package c\n\nconst header = \"// Code generated by x. DO NOT EDIT.\"\nvar tag = \"@generated\"\n", false},
+		{"go header below the limit", "package d\n\n\n\n\n\n\n\n\n\n\n// Code generated by x. DO NOT EDIT.\n", false},
+	}
+
+	for _, tt := range tests {
+		if got := hasGeneratedHeader(tt.content); got != tt.want {
+			t.Errorf("%s: hasGeneratedHeader = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyVendoredPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"vendor/github.com/x/y/y.go", CategoryVendored},
+		{"web/node_modules/left-pad/index.js", CategoryVendored},
+		{"third_party/zlib/inflate.c", CategoryVendored},
+		{"deps/resolver.go", CategorySource},
+		{"internal/deps/graph.go", CategorySource},
+		{"external/api/client.go", CategorySource},
+		{"src/extern/ffi.c", CategorySource},
+	}
+
+	var classifier *fileClassifier
+	for _, tt := range tests {
+		if got, _ := classifier.classifyPath(tt.path); got != tt.want {
+			t.Errorf("%s: category %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	// .gitattributes still marks an ambiguous directory as vendored
+	rule, ok := parseGitAttributeLine("deps/** linguist-vendored")
+	if !ok {
+		t.Fatal("linguist-vendored rule not parsed")
+	}
+	classifier = &fileClassifier{rules: []gitAttributeRule{rule}}
+	if got, reason := classifier.classifyPath("deps/zlib/inflate.c"); got != CategoryVendored || reason != ReasonGitAttributes {
+		t.Errorf("deps/ with linguist-vendored: %q (%s), want vendored from .gitattributes", got, reason)
+	}
+}
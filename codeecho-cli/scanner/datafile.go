@@ -0,0 +1,309 @@
+package scanner
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Data file sampling
+// Why: CSV exports, JSONL dumps and logs are mostly repetition. The model
+// needs their shape (header, a few rows, the schema), not every record.
+
+const (
+	DefaultSampleRows      = 10
+	DefaultSampleThreshold = 32 * 1024 // Only sample files larger than 32KB
+
+	// JSON summaries stop descending after this depth
+	jsonSummaryMaxDepth = 8
+	// How many array elements are merged when inferring an element schema
+	jsonSummaryMaxElements = 20
+	// Example values longer than this are cut short
+	jsonSummaryMaxExample = 40
+)
+
+// DataFileExts are the extensions of the data files that can be sampled
+var DataFileExts = []string{".csv", ".tsv", ".tab", ".jsonl", ".ndjson", ".log", ".sql"}
+
+// dataFileKind classifies a path as a sampleable data format
+func dataFileKind(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return "csv"
+	case ".tsv", ".tab":
+		return "tsv"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	case ".json":
+		return "json"
+	case ".log":
+		return "log"
+	case ".sql":
+		return "sql"
+	}
+	return ""
+}
+
+// sampleDataFile returns a truncated representation of a data file
+// The second return value is false when the file is not a data file
+// or is small enough to be included in full
+func sampleDataFile(path, content string, opts ScanOptions) (string, bool) {
+	kind := dataFileKind(path)
+	if kind == "" {
+		return "", false
+	}
+
+	threshold := opts.DataSampleThreshold
+	if threshold <= 0 {
+		threshold = DefaultSampleThreshold
+	}
+	if int64(len(content)) <= threshold {
+		return "", false
+	}
+
+	rows := opts.DataSampleRows
+	if rows <= 0 {
+		rows = DefaultSampleRows
+	}
+
+	switch kind {
+	case "csv":
+		records, err := splitCSVRecords(content)
+		if err != nil {
+			// Malformed CSV has no reliable records; lines are the best guess
+			records = splitDataLines(content)
+		}
+		return sampleTable(records, rows)
+	case "tsv":
+		// TSV fields cannot hold newlines, so each line is a row
+		return sampleTable(splitDataLines(content), rows)
+	case "jsonl":
+		return sampleHead(content, rows)
+	case "log":
+		return sampleHeadTail(content, rows)
+	case "sql":
+		return sampleSQLDump(content, rows)
+	case "json":
+		return summarizeJSON(content)
+	}
+
+	return "", false
+}
+
+// splitDataLines splits content into lines, dropping the trailing newline
+func splitDataLines(content string) []string {
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// omittedMarker is the line inserted where rows were dropped
+func omittedMarker(count int, unit string) string {
+	return fmt.Sprintf("... [%d %s omitted] ...", count, unit)
+}
+
+// splitCSVRecords splits CSV content into records, each kept as its
+// original text
+// Why: Quoted fields may span lines (RFC 4180), so a line is not a row,
+// and cutting between lines can end the sample inside a field
+func splitCSVRecords(content string) ([]string, error) {
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+
+	var records []string
+	start := int64(0)
+	for {
+		_, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		end := reader.InputOffset()
+		records = append(records, strings.TrimRight(content[start:end], "\r\n"))
+		start = end
+	}
+}
+
+// sampleTable keeps the header plus the first and last N rows
+func sampleTable(lines []string, rows int) (string, bool) {
+	if len(lines) <= 1+rows*2 {
+		return "", false
+	}
+
+	header := lines[0]
+	body := lines[1:]
+
+	var result []string
+	result = append(result, header)
+	result = append(result, body[:rows]...)
+	result = append(result, omittedMarker(len(body)-rows*2, "rows"))
+	result = append(result, body[len(body)-rows:]...)
+
+	return strings.Join(result, "\n") + "\n", true
+}
+
+// sampleHead keeps only the first N lines (one record per line)
+func sampleHead(content string, rows int) (string, bool) {
+	lines := splitDataLines(content)
+	if len(lines) <= rows {
+		return "", false
+	}
+
+	result := append([]string{}, lines[:rows]...)
+	result = append(result, omittedMarker(len(lines)-rows, "records"))
+
+	return strings.Join(result, "\n") + "\n", true
+}
+
+// sampleHeadTail keeps the first and last N lines
+// Why: For logs, the end of the file is usually the interesting part
+func sampleHeadTail(content string, rows int) (string, bool) {
+	lines := splitDataLines(content)
+	if len(lines) <= rows*2 {
+		return "", false
+	}
+
+	var result []string
+	result = append(result, lines[:rows]...)
+	result = append(result, omittedMarker(len(lines)-rows*2, "lines"))
+	result = append(result, lines[len(lines)-rows:]...)
+
+	return strings.Join(result, "\n") + "\n", true
+}
+
+// sampleSQLDump keeps schema statements and the first N INSERT lines
+// Why: DDL describes the data; thousands of INSERTs don't
+func sampleSQLDump(content string, rows int) (string, bool) {
+	lines := splitDataLines(content)
+
+	var result []string
+	inserts := 0
+	omitted := 0
+	for _, line := range lines {
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(line)), "INSERT ") {
+			inserts++
+			if inserts > rows {
+				omitted++
+				continue
+			}
+		}
+		result = append(result, line)
+	}
+
+	if omitted == 0 {
+		return "", false
+	}
+
+	result = append(result, "-- "+omittedMarker(omitted, "INSERT statements"))
+	return strings.Join(result, "\n") + "\n", true
+}
+
+// summarizeJSON replaces a large JSON document with its inferred structure
+// Output shows keys, value types, one example per field and array lengths
+func summarizeJSON(content string) (string, bool) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		// Not valid JSON - fall back to keeping the head of the file
+		return sampleHead(content, DefaultSampleRows*5)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("// JSON structure summary (values are examples)\n")
+	writeJSONShape(&builder, doc, 0)
+	builder.WriteString("\n")
+
+	return builder.String(), true
+}
+
+func writeJSONShape(b *strings.Builder, value interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		if depth >= jsonSummaryMaxDepth {
+			b.WriteString(fmt.Sprintf("{ ... %d keys }", len(v)))
+			return
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("{\n")
+		for _, key := range keys {
+			b.WriteString(fmt.Sprintf("%s  %q: ", indent, key))
+			writeJSONShape(b, v[key], depth+1)
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "}")
+
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString("array[0]")
+			return
+		}
+		b.WriteString(fmt.Sprintf("array[%d] of ", len(v)))
+		if depth >= jsonSummaryMaxDepth {
+			b.WriteString("...")
+			return
+		}
+		writeJSONShape(b, mergeJSONElements(v), depth)
+
+	case string:
+		example := v
+		if len(example) > jsonSummaryMaxExample {
+			example = example[:jsonSummaryMaxExample] + "..."
+		}
+		b.WriteString(fmt.Sprintf("string (e.g. %q)", example))
+
+	case float64:
+		b.WriteString(fmt.Sprintf("number (e.g. %v)", v))
+
+	case bool:
+		b.WriteString(fmt.Sprintf("boolean (e.g. %t)", v))
+
+	case nil:
+		b.WriteString("null")
+	}
+}
+
+// mergeJSONElements builds one representative element from an array
+// Why: Objects in an array often have optional keys, so a single element
+// would under-report the schema
+func mergeJSONElements(elements []interface{}) interface{} {
+	limit := len(elements)
+	if limit > jsonSummaryMaxElements {
+		limit = jsonSummaryMaxElements
+	}
+
+	var merged map[string]interface{}
+	for _, element := range elements[:limit] {
+		obj, ok := element.(map[string]interface{})
+		if !ok {
+			// Mixed or scalar arrays: the first element is representative enough
+			return elements[0]
+		}
+		if merged == nil {
+			merged = make(map[string]interface{})
+		}
+		for key, value := range obj {
+			if existing, seen := merged[key]; !seen || existing == nil {
+				merged[key] = value
+			}
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSampleCSVQuotedNewlines(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id,note\n")
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&b, "%d,\"line one of %d\nline two, with \"\"quotes\"\"\"\n", i, i)
+	}
+
+	sample, ok := sampleDataFile("notes.csv", b.String(), ScanOptions{DataSampleRows: 5, DataSampleThreshold: 1})
+	if !ok {
+		t.Fatal("file was not sampled")
+	}
+	if !strings.Contains(sample, "[2990 rows omitted]") {
+		t.Errorf("wrong omitted count in sample:\n%s", sample)
+	}
+
+	// The sample is valid CSV once the marker line is taken out
+	var kept []string
+	for _, line := range strings.Split(sample, "\n") {
+		if !strings.Contains(line, "rows omitted") {
+			kept = append(kept, line)
+		}
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.Join(kept, "\n"))).ReadAll()
+	if err != nil {
+		t.Fatalf("sample is not valid CSV: %v\n%s", err, sample)
+	}
+	if len(records) != 11 {
+		t.Errorf("got %d records, want the header and 10 rows", len(records))
+	}
+	if got := records[len(records)-1][1]; got != "line one of 2999\nline two, with \"quotes\"" {
+		t.Errorf("last record's field = %q", got)
+	}
+}
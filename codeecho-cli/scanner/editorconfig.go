@@ -0,0 +1,270 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EditorConfig support
+// Why: Indentation is project policy, not something to guess per file.
+// .editorconfig says it outright, and --compress-code needs it to re-indent.
+
+// editorConfigSection is one [glob] section of an .editorconfig file
+type editorConfigSection struct {
+	pattern    *regexp.Regexp
+	properties map[string]string
+}
+
+// editorConfigFile is a parsed .editorconfig
+type editorConfigFile struct {
+	dir      string
+	root     bool
+	sections []editorConfigSection
+}
+
+// editorConfigResolver finds the .editorconfig files that apply to a path
+// Only directories inside the scan root are searched so output doesn't
+// depend on the machine the scan runs on
+type editorConfigResolver struct {
+	rootPath string
+	cache    map[string]*editorConfigFile // nil entry = no .editorconfig in that dir
+}
+
+func newEditorConfigResolver(rootPath string) *editorConfigResolver {
+	return &editorConfigResolver{
+		rootPath: filepath.Clean(rootPath),
+		cache:    make(map[string]*editorConfigFile),
+	}
+}
+
+// indentation returns the indent_style and indent_size that apply to path
+// The error reports an unreadable .editorconfig; it is returned once per file
+func (r *editorConfigResolver) indentation(path string) (string, int, error) {
+	if r == nil {
+		return "", 0, nil
+	}
+
+	// Collect .editorconfig files from the file's directory up to the scan root
+	var files []*editorConfigFile
+	var firstErr error
+	dir := filepath.Dir(filepath.Clean(path))
+	for {
+		file, err := r.load(dir)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if file != nil {
+			files = append(files, file)
+			if file.root {
+				break
+			}
+		}
+		if dir == r.rootPath || !strings.HasPrefix(dir, r.rootPath) {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	// Apply outermost first so closer files (and later sections) win
+	properties := make(map[string]string)
+	for i := len(files) - 1; i >= 0; i-- {
+		file := files[i]
+		rel, err := filepath.Rel(file.dir, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, section := range file.sections {
+			if !section.pattern.MatchString(rel) {
+				continue
+			}
+			for key, value := range section.properties {
+				properties[key] = value
+			}
+		}
+	}
+
+	style, size := editorConfigIndent(properties)
+	return style, size, firstErr
+}
+
+// editorConfigIndent resolves indent_size = tab and tab_width fallbacks
+func editorConfigIndent(properties map[string]string) (string, int) {
+	style := ""
+	switch properties["indent_style"] {
+	case IndentStyleTab:
+		style = IndentStyleTab
+	case IndentStyleSpace:
+		style = IndentStyleSpace
+	}
+
+	tabWidth, _ := strconv.Atoi(properties["tab_width"])
+	size := 0
+	if properties["indent_size"] == "tab" {
+		size = tabWidth
+	} else {
+		size, _ = strconv.Atoi(properties["indent_size"])
+	}
+	if size == 0 && style == IndentStyleTab {
+		size = tabWidth
+	}
+
+	return style, size
+}
+
+// load parses dir/.editorconfig once and caches the result
+func (r *editorConfigResolver) load(dir string) (*editorConfigFile, error) {
+	if file, ok := r.cache[dir]; ok {
+		return file, nil
+	}
+
+	file, err := parseEditorConfig(dir)
+	r.cache[dir] = file
+	return file, err
+}
+
+func parseEditorConfig(dir string) (*editorConfigFile, error) {
+	path := filepath.Join(dir, ".editorconfig")
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .editorconfig: %w", err)
+	}
+	defer f.Close()
+
+	file := &editorConfigFile{dir: dir}
+	var current *editorConfigSection
+
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			pattern, err := compileEditorConfigGlob(line[1 : len(line)-1])
+			if err != nil {
+				// Skip sections we can't match rather than failing the scan
+				current = nil
+				continue
+			}
+			file.sections = append(file.sections, editorConfigSection{pattern: pattern, properties: make(map[string]string)})
+			current = &file.sections[len(file.sections)-1]
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		if current == nil {
+			// Preamble: only "root" is meaningful
+			if key == "root" {
+				file.root = value == "true"
+			}
+			continue
+		}
+		current.properties[key] = value
+	}
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse .editorconfig: %w", err)
+	}
+
+	return file, nil
+}
+
+var editorConfigRangePattern = regexp.MustCompile(`^(-?\d+)\.\.(-?\d+)$`)
+
+// compileEditorConfigGlob converts an EditorConfig glob to a regexp
+// Supports *, **, ?, [seq], [!seq], {a,b} and {n1..n2}
+// A glob without "/" matches the file name in any directory
+func compileEditorConfigGlob(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+
+	if !strings.Contains(glob, "/") {
+		b.WriteString(`(?:^|.*/)`)
+	} else {
+		b.WriteString(`^`)
+		glob = strings.TrimPrefix(glob, "/")
+	}
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				b.WriteString(`.*`)
+				i++
+			} else {
+				b.WriteString(`[^/]*`)
+			}
+		case '?':
+			b.WriteString(`[^/]`)
+		case '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		case '{':
+			end := strings.IndexByte(glob[i:], '}')
+			if end < 0 {
+				b.WriteString(`\{`)
+				continue
+			}
+			b.WriteString(editorConfigBraces(glob[i+1 : i+end]))
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString(`$`)
+
+	return regexp.Compile(b.String())
+}
+
+// editorConfigBraces expands {a,b,c} and {1..3} into a regexp group
+func editorConfigBraces(body string) string {
+	if m := editorConfigRangePattern.FindStringSubmatch(body); m != nil {
+		lo, _ := strconv.Atoi(m[1])
+		hi, _ := strconv.Atoi(m[2])
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if hi-lo > 1000 {
+			return `-?\d+`
+		}
+		var numbers []string
+		for n := lo; n <= hi; n++ {
+			numbers = append(numbers, strconv.Itoa(n))
+		}
+		return `(?:` + strings.Join(numbers, "|") + `)`
+	}
+
+	options := strings.Split(body, ",")
+	for i, option := range options {
+		options[i] = strings.ReplaceAll(regexp.QuoteMeta(option), `\*`, `[^/]*`)
+	}
+	return `(?:` + strings.Join(options, "|") + `)`
+}
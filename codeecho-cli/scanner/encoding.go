@@ -0,0 +1,273 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Encoding detection and transcoding
+// Why: Windows tooling still writes UTF-16 (.cs, .ps1) and legacy code pages.
+// Everything downstream assumes UTF-8, so we sniff the charset and convert first.
+
+// Encoding names recorded in FileInfo.Encoding
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF8BOM     = "utf-8-bom"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingWindows1252 = "windows-1252"
+	EncodingLatin1      = "iso-8859-1"
+	EncodingShiftJIS    = "shift_jis"
+)
+
+// Line ending styles recorded in FileInfo.LineEnding
+const (
+	LineEndingLF    = "lf"
+	LineEndingCRLF  = "crlf"
+	LineEndingCR    = "cr"
+	LineEndingMixed = "mixed"
+)
+
+// Sample size for charset sniffing (same as text detection)
+const encodingSampleSize = 8192
+
+// detectEncoding guesses the character encoding of data
+// Returns "" when the data looks binary
+// Algorithm:
+//  1. Byte order marks
+//  2. UTF-16 without BOM (NUL bytes on alternating positions)
+//  3. Valid UTF-8
+//  4. Shift-JIS byte structure
+//  5. Windows-1252 vs Latin-1 (C1 control range usage)
+func detectEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8BOM
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	}
+
+	sample := data
+	if len(sample) > encodingSampleSize {
+		sample = sample[:encodingSampleSize]
+	}
+
+	if enc := detectUTF16WithoutBOM(sample); enc != "" {
+		return enc
+	}
+
+	// Any other NUL byte means binary
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return ""
+	}
+
+	if validUTF8Prefix(sample, len(data) > len(sample)) {
+		return EncodingUTF8
+	}
+
+	if isShiftJIS(sample) {
+		return EncodingShiftJIS
+	}
+
+	if usesWindows1252(sample) {
+		return EncodingWindows1252
+	}
+	return EncodingLatin1
+}
+
+// detectUTF16WithoutBOM spots BOM-less UTF-16 from its NUL byte pattern
+// Why: Mostly-ASCII UTF-16 has a NUL in every other byte
+func detectUTF16WithoutBOM(sample []byte) string {
+	if len(sample) < 4 {
+		return ""
+	}
+
+	evenZeros, oddZeros := 0, 0
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+
+	pairs := len(sample) / 2
+	switch {
+	case oddZeros > pairs*3/10 && evenZeros < pairs/20:
+		return EncodingUTF16LE
+	case evenZeros > pairs*3/10 && oddZeros < pairs/20:
+		return EncodingUTF16BE
+	}
+	return ""
+}
+
+// validUTF8Prefix validates a sample that may end mid-rune
+func validUTF8Prefix(sample []byte, truncated bool) bool {
+	if truncated && len(sample) > 0 {
+		// Drop an incomplete trailing rune cut off by the sample boundary
+		start := len(sample) - 1
+		for start > 0 && start > len(sample)-utf8.UTFMax && !utf8.RuneStart(sample[start]) {
+			start--
+		}
+		if !utf8.FullRune(sample[start:]) {
+			sample = sample[:start]
+		}
+	}
+	return utf8.Valid(sample)
+}
+
+// isShiftJIS checks the sample is structurally valid Shift-JIS
+// and looks like Japanese text rather than accented Latin-1
+// Why: A Latin-1 "ï" followed by an ASCII letter is also a valid Shift-JIS
+// pair, but Japanese text has runs of double-byte characters and high trail bytes
+func isShiftJIS(sample []byte) bool {
+	pairs, strong := 0, 0
+	run := 0
+	prevWeak := false
+	for i := 0; i < len(sample); i++ {
+		b := sample[i]
+		switch {
+		case b < 0x80:
+			run, prevWeak = 0, false
+			continue
+		case b >= 0xA1 && b <= 0xDF:
+			// Half-width katakana
+			run, prevWeak = 0, false
+			continue
+		case (b >= 0x81 && b <= 0x9F) || (b >= 0xE0 && b <= 0xFC):
+			if i+1 >= len(sample) {
+				// Lead byte cut off by the sample boundary
+				break
+			}
+			trail := sample[i+1]
+			if trail < 0x40 || trail == 0x7F || trail > 0xFC {
+				return false
+			}
+			pairs++
+			run++
+			if trail >= 0x80 || run > 1 {
+				strong++
+				if prevWeak {
+					// The previous pair turned out to start a run
+					strong++
+				}
+				prevWeak = false
+			} else {
+				prevWeak = true
+			}
+			i++
+		default:
+			return false
+		}
+	}
+	return pairs > 0 && strong*2 >= pairs
+}
+
+// usesWindows1252 reports whether the sample uses bytes that Windows-1252
+// maps to printable characters (smart quotes, dashes, euro) where Latin-1
+// has C1 control codes
+func usesWindows1252(sample []byte) bool {
+	for _, b := range sample {
+		if b >= 0x80 && b <= 0x9F {
+			switch b {
+			case 0x81, 0x8D, 0x8F, 0x90, 0x9D:
+				// Undefined in Windows-1252
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// textDecoder returns the decoder for a detected encoding
+// UTF-8 needs no decoder (nil); the BOM variant only strips the mark
+func textDecoder(enc string) *encoding.Decoder {
+	switch enc {
+	case EncodingUTF8BOM:
+		return unicode.UTF8BOM.NewDecoder()
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+	case EncodingWindows1252:
+		return charmap.Windows1252.NewDecoder()
+	case EncodingLatin1:
+		return charmap.ISO8859_1.NewDecoder()
+	case EncodingShiftJIS:
+		return japanese.ShiftJIS.NewDecoder()
+	}
+	return nil
+}
+
+// transcodeToUTF8 converts data from enc to UTF-8
+func transcodeToUTF8(data []byte, enc string) ([]byte, error) {
+	decoder := textDecoder(enc)
+	if decoder == nil {
+		return data, nil
+	}
+
+	decoded, err := decoder.Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", enc, err)
+	}
+	return decoded, nil
+}
+
+// detectLineEnding classifies the line terminators used in text
+func detectLineEnding(text string) string {
+	crlf := strings.Count(text, "\r\n")
+	cr := strings.Count(text, "\r") - crlf
+	lf := strings.Count(text, "\n") - crlf
+
+	styles := 0
+	style := ""
+	if lf > 0 {
+		styles++
+		style = LineEndingLF
+	}
+	if crlf > 0 {
+		styles++
+		style = LineEndingCRLF
+	}
+	if cr > 0 {
+		styles++
+		style = LineEndingCR
+	}
+
+	if styles > 1 {
+		return LineEndingMixed
+	}
+	return style
+}
+
+// readSample reads up to encodingSampleSize bytes from the start of a file
+// Why: Lets us classify unknown files without loading large binaries
+func readSample(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sample := make([]byte, encodingSampleSize)
+	n, err := io.ReadFull(f, sample)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return sample[:n], nil
+}
@@ -0,0 +1,207 @@
+package scanner
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Language disambiguation heuristics
+// Why: Extensions like .h, .m and .pl belong to several languages. The first
+// few KB of content almost always settle it (an @interface, a :- clause).
+
+//go:embed heuristics.yml
+var embeddedHeuristics []byte
+
+// Detection methods recorded in FileInfo.DetectionMethod
+const (
+	DetectionFilename  = "filename"
+	DetectionExtension = "extension"
+	DetectionShebang   = "shebang"
+	DetectionHeuristic = "heuristic"
+	DetectionContent   = "content"
+)
+
+// Confidence recorded in FileInfo.LanguageConfidence per method
+// Ambiguous extensions score 1/candidates until content settles them
+const (
+	confidenceFilename  = 1.0
+	confidenceShebang   = 0.95
+	confidenceExtension = 0.9
+	confidenceHeuristic = 0.8
+	confidenceContent   = 0.6
+)
+
+// Only the start of a file is searched by heuristics
+const heuristicSampleSize = 4096
+
+// heuristicPatterns accepts a single regexp or a list of them
+type heuristicPatterns []string
+
+func (p *heuristicPatterns) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*p = heuristicPatterns{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*p = list
+	return nil
+}
+
+type heuristicRule struct {
+	Language        string            `yaml:"language"`
+	Pattern         heuristicPatterns `yaml:"pattern"`
+	NegativePattern heuristicPatterns `yaml:"negative_pattern"`
+
+	patterns  []*regexp.Regexp
+	negatives []*regexp.Regexp
+}
+
+type heuristicDisambiguation struct {
+	Extensions []string        `yaml:"extensions"`
+	Rules      []heuristicRule `yaml:"rules"`
+}
+
+type heuristicsFile struct {
+	Disambiguations []heuristicDisambiguation `yaml:"disambiguations"`
+	Content         []heuristicRule           `yaml:"content"`
+}
+
+// languageHeuristics holds compiled rules indexed by extension
+type languageHeuristics struct {
+	byExtension map[string][]heuristicRule
+	content     []heuristicRule
+}
+
+var (
+	heuristicsOnce sync.Once
+	heuristics     *languageHeuristics
+)
+
+// defaultHeuristics compiles the embedded heuristics on first use
+func defaultHeuristics() *languageHeuristics {
+	heuristicsOnce.Do(func() {
+		var file heuristicsFile
+		if err := yaml.Unmarshal(embeddedHeuristics, &file); err != nil {
+			panic(fmt.Sprintf("invalid embedded heuristics.yml: %v", err))
+		}
+
+		heuristics = &languageHeuristics{byExtension: make(map[string][]heuristicRule)}
+		for _, d := range file.Disambiguations {
+			rules, err := compileHeuristicRules(d.Rules)
+			if err != nil {
+				panic(fmt.Sprintf("invalid embedded heuristics.yml: %v", err))
+			}
+			for _, ext := range d.Extensions {
+				heuristics.byExtension[strings.ToLower(ext)] = rules
+			}
+		}
+
+		rules, err := compileHeuristicRules(file.Content)
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded heuristics.yml: %v", err))
+		}
+		heuristics.content = rules
+	})
+	return heuristics
+}
+
+func compileHeuristicRules(rules []heuristicRule) ([]heuristicRule, error) {
+	compiled := make([]heuristicRule, len(rules))
+	for i, rule := range rules {
+		if _, ok := LookupLanguage(rule.Language); !ok {
+			return nil, fmt.Errorf("unknown language %q", rule.Language)
+		}
+		for _, expr := range rule.Pattern {
+			re, err := regexp.Compile(`(?m)` + expr)
+			if err != nil {
+				return nil, fmt.Errorf("language %q: %w", rule.Language, err)
+			}
+			rule.patterns = append(rule.patterns, re)
+		}
+		for _, expr := range rule.NegativePattern {
+			re, err := regexp.Compile(`(?m)` + expr)
+			if err != nil {
+				return nil, fmt.Errorf("language %q: %w", rule.Language, err)
+			}
+			rule.negatives = append(rule.negatives, re)
+		}
+		compiled[i] = rule
+	}
+	return compiled, nil
+}
+
+// matches reports whether the rule applies to sample
+// A rule without patterns always matches (it is the fallback)
+func (rule heuristicRule) matches(sample []byte) bool {
+	for _, re := range rule.negatives {
+		if re.Match(sample) {
+			return false
+		}
+	}
+	if len(rule.patterns) == 0 {
+		return true
+	}
+	for _, re := range rule.patterns {
+		if re.Match(sample) {
+			return true
+		}
+	}
+	return false
+}
+
+// disambiguate picks one of the candidate languages for an ambiguous path
+// Returns false when no rule for the extension matched a candidate
+func disambiguate(ext string, candidates []*Language, content []byte) (string, float64, bool) {
+	rules := defaultHeuristics().byExtension[strings.ToLower(ext)]
+	if len(rules) == 0 {
+		return "", 0, false
+	}
+
+	sample := heuristicSample(content)
+	for _, rule := range rules {
+		if !isCandidate(rule.Language, candidates) || !rule.matches(sample) {
+			continue
+		}
+		if len(rule.patterns) == 0 {
+			// Fallback: still a guess between the candidates
+			return rule.Language, 1 / float64(len(candidates)), true
+		}
+		return rule.Language, confidenceHeuristic, true
+	}
+	return "", 0, false
+}
+
+// matchContentRules identifies a file with no known extension from its content
+func matchContentRules(content []byte) string {
+	sample := heuristicSample(content)
+	for _, rule := range defaultHeuristics().content {
+		if len(rule.patterns) > 0 && rule.matches(sample) {
+			return rule.Language
+		}
+	}
+	return ""
+}
+
+func isCandidate(id string, candidates []*Language) bool {
+	for _, lang := range candidates {
+		if lang.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func heuristicSample(content []byte) []byte {
+	if len(content) > heuristicSampleSize {
+		return content[:heuristicSampleSize]
+	}
+	return content
+}
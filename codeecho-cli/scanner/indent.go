@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"strings"
+)
+
+// Indentation handling
+// Why: --compress-code used to squash every run of spaces, flattening the
+// nesting that makes code readable. Re-indenting to one tab per level keeps
+// the structure at a fraction of the characters.
+
+// Indent styles recorded in FileInfo.IndentStyle
+const (
+	IndentStyleTab   = "tab"
+	IndentStyleSpace = "space"
+)
+
+// Used when neither .editorconfig, a modeline nor the content says otherwise
+const defaultIndentSize = 4
+
+// Languages whose indentation carries no meaning, so re-indenting is safe
+// Why: Markup (<pre>, <textarea>), indentation-sensitive languages and
+// heredocs in shell, Ruby, Perl or PHP change meaning when leading
+// whitespace is rewritten; every other language keeps its spaces
+var reindentSafe = map[string]bool{
+	"c": true, "cpp": true, "cuda": true, "objective-c": true, "objective-cpp": true,
+	"csharp": true, "java": true, "kotlin": true, "scala": true, "groovy": true,
+	"swift": true, "rust": true, "go": true, "zig": true, "d": true, "dart": true,
+	"javascript": true, "typescript": true, "jsx": true, "tsx": true,
+	"css": true, "scss": true, "less": true, "lua": true, "solidity": true,
+	"glsl": true, "hlsl": true, "wgsl": true, "protobuf": true, "thrift": true,
+	"graphql": true,
+}
+
+// inferIndentation guesses indent style and size from leading whitespace
+func inferIndentation(content string) (string, int) {
+	tabs, spaces := 0, 0
+	sizes := make(map[int]int)
+	previous := 0
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		switch line[0] {
+		case '\t':
+			tabs++
+		case ' ':
+			spaces++
+		}
+
+		width := len(line) - len(strings.TrimLeft(line, " "))
+		if line[0] == ' ' && width > previous {
+			sizes[width-previous]++
+		}
+		previous = width
+	}
+
+	if tabs == 0 && spaces == 0 {
+		return "", 0
+	}
+	if tabs > spaces {
+		return IndentStyleTab, defaultIndentSize
+	}
+
+	// The most common increase between consecutive lines is the indent unit
+	size, best := defaultIndentSize, 0
+	for _, candidate := range []int{2, 4, 3, 8} {
+		if sizes[candidate] > best {
+			size, best = candidate, sizes[candidate]
+		}
+	}
+	return IndentStyleSpace, size
+}
+
+// reindent rewrites leading whitespace as one tab per indent level
+// Partial levels (alignment of continuation lines) are kept as spaces
+func reindent(content string, size int) string {
+	if size <= 0 {
+		size = defaultIndentSize
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || len(trimmed) == len(line) {
+			continue
+		}
+
+		width := 0
+		for _, c := range line[:len(line)-len(trimmed)] {
+			if c == '\t' {
+				width += size - width%size
+			} else {
+				width++
+			}
+		}
+		lines[i] = strings.Repeat("\t", width/size) + strings.Repeat(" ", width%size) + trimmed
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// canReindent reports whether a language tolerates re-indentation
+func canReindent(language string) bool {
+	return reindentSafe[language]
+}
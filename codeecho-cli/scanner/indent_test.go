@@ -0,0 +1,23 @@
+package scanner
+
+import "testing"
+
+func TestCompressWhitespaceReindent(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		content  string
+		want     string
+	}{
+		{"go", "go", "func f() {\n    if x {\n        y()\n    }\n}\n", "func f() {\n\tif x {\n\t\ty()\n\t}\n}\n"},
+		{"html pre", "html", "<pre>\n    indented\n        more\n</pre>\n", "<pre>\n    indented\n        more\n</pre>\n"},
+		{"shell heredoc", "shell", "cat <<EOF\n    kept\nEOF\n", "cat <<EOF\n    kept\nEOF\n"},
+		{"python", "python", "def f():\n    return 1\n", "def f():\n    return 1\n"},
+	}
+
+	for _, tt := range tests {
+		if got := compressWhitespace(tt.content, tt.language, 4); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
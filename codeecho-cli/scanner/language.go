@@ -7,36 +7,20 @@ import (
 	"unicode/utf8"
 )
 
-func detectLanguage(path string) string {
-	ext := strings.ToLower(filepath.Ext(path))
-	langMap := map[string]string{
-		".go":   "go",
-		".js":   "javascript",
-		".ts":   "typescript",
-		".jsx":  "jsx",
-		".tsx":  "tsx",
-		".py":   "python",
-		".java": "java",
-		".cpp":  "cpp",
-		".c":    "c",
-		".h":    "c",
-		".rs":   "rust",
-		".rb":   "ruby",
-		".php":  "php",
-		".css":  "css",
-		".html": "html",
-		".json": "json",
-		".md":   "markdown",
-		".yml":  "yaml",
-		".yaml": "yaml",
-		".toml": "toml",
-		".xml":  "xml",
-	}
-
-	if lang, exists := langMap[ext]; exists {
-		return lang
-	}
-	return ""
+// detectLanguage picks a language from the path alone
+// Returns the language ID, the detection method and a confidence score;
+// ambiguous extensions get a low score so content can overrule them
+func detectLanguage(path string) (string, string, float64) {
+	langs, method, _ := languagesForPath(path)
+	switch {
+	case len(langs) == 0:
+		return "", "", 0
+	case len(langs) > 1:
+		return langs[0].ID, method, 1 / float64(len(langs))
+	case method == DetectionFilename:
+		return langs[0].ID, method, confidenceFilename
+	}
+	return langs[0].ID, method, confidenceExtension
 }
 
 // ENHANCED: Now checks content for unknown types
@@ -60,48 +44,22 @@ func isTextFile(path, extension string) bool {
 }
 
 // Separated extension checking for clarity
-// Makes the logic clearer and easier to maintain
+// Every registry language is text; the registry is the single source of truth
 func isTextExtension(ext string) bool {
-	textExtensions := map[string]bool{
-		".txt": true, ".md": true, ".rst": true, ".asciidoc": true,
-		".go": true, ".py": true, ".js": true, ".ts": true, ".jsx": true, ".tsx": true,
-		".java": true, ".c": true, ".cpp": true, ".cc": true, ".cxx": true, ".h": true, ".hpp": true,
-		".cs": true, ".php": true, ".rb": true, ".rs": true, ".swift": true, ".kt": true,
-		".html": true, ".htm": true, ".xml": true, ".xhtml": true,
-		".css": true, ".scss": true, ".sass": true, ".less": true,
-		".json": true, ".yaml": true, ".yml": true, ".toml": true, ".ini": true, ".cfg": true, ".conf": true,
-		".sh": true, ".bash": true, ".zsh": true, ".fish": true, ".ps1": true, ".bat": true, ".cmd": true,
-		".sql": true, ".graphql": true, ".gql": true,
-		".dockerfile": true, ".gitignore": true, ".gitattributes": true,
-		".makefile": true, ".cmake": true,
-		".r": true, ".rmd": true, ".m": true, ".scala": true, ".clj": true, ".hs": true,
-		".vim": true, ".lua": true, ".pl": true, ".tcl": true,
-		".tex": true, ".bib": true, ".cls": true, ".sty": true,
-		".csv": true, ".tsv": true, ".log": true,
-	}
-	return textExtensions[ext]
+	return registryHasExtension(ext)
 }
 
 // Separated filename checking for clarity
 // Keeps the logic organized and testable
 func isTextFilename(fileName string) bool {
-	textFiles := map[string]bool{
-		"readme": true, "license": true, "changelog": true, "contributing": true,
-		"authors": true, "contributors": true, "copying": true, "install": true,
-		"news": true, "thanks": true, "todo": true, "version": true,
-		"makefile": true, "dockerfile": true, "jenkinsfile": true,
-		"gemfile": true, "rakefile": true, "guardfile": true, "procfile": true,
-		".gitignore": true, ".gitattributes": true, ".dockerignore": true,
-		".eslintrc": true, ".prettierrc": true, ".babelrc": true,
-	}
-	return textFiles[fileName]
+	return registryHasFilename(fileName)
 }
 
 // Content-based text detection
 // Last resort for files we can't identify by name/extension
 // Algorithm:
-//   1. Check for null bytes (binary indicator)
-//   2. Validate UTF-8 encoding
+//   1. Detect the encoding (BOMs, UTF-16, UTF-8, legacy code pages)
+//   2. Decode the sample to UTF-8
 //   3. Check printable character ratio
 
 func isTextContent(data []byte) bool {
@@ -118,60 +76,63 @@ func isTextContent(data []byte) bool {
 	}
 	sample := data[:sampleSize]
 
-	// Rule 1: Binary files often contain null bytes
-	// Why: Text files rarely have \x00 characters
-	if bytes.Contains(sample, []byte{0}) {
+	// Rule 1: No plausible text encoding means binary
+	// Why: NUL bytes outside a UTF-16 pattern don't occur in text
+	enc := detectEncoding(sample)
+	if enc == "" {
 		return false
 	}
 
-	// Rule 2: Text files must be valid UTF-8
-	// Why: If it's not valid UTF-8, it's likely binary
-	if !utf8.Valid(sample) {
-		// Allow some invalid UTF-8 for legacy encodings
-		// If more than 10% is invalid, it's likely binary
-		invalidCount := 0
-		for len(sample) > 0 {
-			r, size := utf8.DecodeRune(sample)
-			if r == utf8.RuneError && size == 1 {
-				invalidCount++
-			}
-			sample = sample[size:]
-		}
-		invalidRatio := float64(invalidCount) / float64(sampleSize)
-		if invalidRatio > 0.1 {
-			return false
-		}
+	// Rule 2: Decode legacy/UTF-16 samples before judging them
+	// Why: UTF-16 is half NUL bytes, Latin-1 is all "invalid" UTF-8
+	decoded, err := transcodeToUTF8(sample, enc)
+	if err != nil {
+		return false
 	}
 
 	// Rule 3: Check printable character ratio
 	// Text files should be mostly printable
-	printableCount := 0
-	for _, b := range sample {
-		// Printable ASCII: 0x20-0x7E, plus common whitespace
-		if (b >= 0x20 && b <= 0x7E) || b == '\r' || b == '\t' {
-			printableCount++
+	total, printable := 0, 0
+	for _, r := range string(decoded) {
+		total++
+		if r == '\n' || r == '\r' || r == '\t' || (r >= 0x20 && r != 0x7F && r != utf8.RuneError && !(r >= 0x80 && r < 0xA0)) {
+			printable++
 		}
 	}
-	printableRatio := float64(printableCount) / float64(len(sample))
+	if total == 0 {
+		return true
+	}
+	printableRatio := float64(printable) / float64(total)
 	// If 80%+ is printable, it's probably text
-	// 80%? Allows for some special characters in UTF-8
+	// 80%? Allows for some control characters in logs and ANSI output
 	return printableRatio >= 0.8
 }
 
-// Detect language from file content (shebang, patterns)
-// Files without extensions need content analysis
-func detectLanguageFromContent(path string, content []byte) string {
+// Detect language from file content (shebang, heuristics, patterns)
+// Used when the path gave no language or an ambiguous one
+func detectLanguageFromContent(path string, content []byte) (string, string, float64) {
 	// try shebang for scripts
 	if lang := detectFromShebang(content); lang != "" {
-		return lang
+		return lang, DetectionShebang, confidenceShebang
 	}
 
-	// try content patterns
-	if lang := detectFromPatterns(content); lang != "" {
-		return lang
+	// disambiguate extensions several languages claim
+	langs, _, ext := languagesForPath(path)
+	if len(langs) > 1 {
+		if lang, confidence, ok := disambiguate(ext, langs, content); ok {
+			return lang, DetectionHeuristic, confidence
+		}
+		return "", "", 0
 	}
 
-	return ""
+	// try content patterns for unclaimed files
+	if len(langs) == 0 {
+		if lang := detectFromPatterns(content); lang != "" {
+			return lang, DetectionContent, confidenceContent
+		}
+	}
+
+	return "", "", 0
 }
 
 // Shebang detection
@@ -187,61 +148,31 @@ func detectFromShebang(content []byte) string {
 		firstLine = content[:idx]
 	}
 
-	shebang := string(firstLine)
-
-	// Common shebang patterns
-	patterns := map[string]string{
-		"python":    "python",
-		"node":      "javascript",
-		"ruby":      "ruby",
-		"perl":      "perl",
-		"bash":      "bash",
-		"sh":        "shell",
-		"/bin/sh":   "shell",
-		"/bin/bash": "bash",
-		"php":       "php",
+	// "#!/usr/bin/env -S python3 -u" -> python3
+	fields := strings.Fields(strings.TrimPrefix(string(firstLine), "#!"))
+	if len(fields) == 0 {
+		return ""
 	}
-
-	for pattern, lang := range patterns {
-		if strings.Contains(strings.ToLower(shebang), pattern) {
-			return lang
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+				continue
+			}
+			interpreter = filepath.Base(field)
+			break
 		}
 	}
 
+	if langs := languagesForInterpreter(interpreter); len(langs) > 0 {
+		return langs[0].ID
+	}
 	return ""
 }
 
 // Pattern-based detection
-// Some file types have distinctive patterns
+// Some file types have distinctive patterns (rules live in heuristics.yml)
 func detectFromPatterns(content []byte) string {
-	// Sample first 1KB for pattern matching
-	// Why 1KB? Most file signatures appear early
-	sampleSize := 1024
-	if len(content) < sampleSize {
-		sampleSize = len(content)
-	}
-	sample := strings.ToLower(string(content[:sampleSize]))
-
-	// Check for distinctive patterns
-	patterns := []struct {
-		pattern string
-		lang    string
-	}{
-		{"<?php", "php"},
-		{"<?xml", "xml"},
-		{"<!doctype html", "html"},
-		{"<html", "html"},
-		{"import react", "jsx"},
-		{"from react", "jsx"},
-		{"package main", "go"},
-		{"#!/usr/bin/env python", "python"},
-	}
-
-	for _, p := range patterns {
-		if strings.Contains(sample, p.pattern) {
-			return p.lang
-		}
-	}
-
-	return ""
+	return matchContentRules(content)
 }
@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+)
+
+// ManifestDigestPrefix names the hash in a written manifest digest
+const ManifestDigestPrefix = "sha256:"
+
+// Manifest accumulates a digest over every file written to a pack
+// Each file adds the line "<sha256> <content_sha256> <path>\n", with "-"
+// for a missing hash, in output order. Anyone holding the pack can
+// recompute it, so a changed, added or removed entry changes the digest
+type Manifest struct {
+	hash  hash.Hash
+	files int
+}
+
+// NewManifest starts an empty manifest
+func NewManifest() *Manifest {
+	return &Manifest{hash: sha256.New()}
+}
+
+// Add records one file
+func (m *Manifest) Add(path, originalSHA256, contentSHA256 string) {
+	fmt.Fprintf(m.hash, "%s %s %s\n", orDash(originalSHA256), orDash(contentSHA256), path)
+	m.files++
+}
+
+// Files is the number of files added
+func (m *Manifest) Files() int {
+	return m.files
+}
+
+// Digest returns "sha256:<hex>" over the files added so far
+func (m *Manifest) Digest() string {
+	return ManifestDigestPrefix + hex.EncodeToString(m.hash.Sum(nil))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Vim and Emacs modelines
+// Why: Extensionless scripts often say what they are in a modeline
+// ("# vim: set ft=python:", "-*- mode: ruby -*-"). It is an explicit
+// declaration by the author, so it outranks every other signal.
+
+// DetectionModeline is recorded when a modeline named the language
+const DetectionModeline = "modeline"
+
+const confidenceModeline = 1.0
+
+// Vim only looks at the first and last few lines (the 'modelines' option)
+const modelineSearchLines = 5
+
+var (
+	// vim: set ft=python ts=4 sw=4 et:   /   vi: filetype=sh
+	vimModelinePattern = regexp.MustCompile(`(?:^|\s)(?:vi|vim|Vim|ex)(?:[<=>]?\d+)?:\s*(?:set?\s+)?(.*)`)
+	vimOptionPattern   = regexp.MustCompile(`\b(ft|filetype|syn|syntax|ts|tabstop|sw|shiftwidth|sts|softtabstop|et|expandtab|noet|noexpandtab)\b(?:\s*=\s*([\w+#.-]+))?`)
+
+	// -*- mode: ruby; tab-width: 2 -*-   /   -*- python -*-
+	emacsModelinePattern = regexp.MustCompile(`-\*-\s*(.*?)\s*-\*-`)
+)
+
+// modeline holds the settings a modeline declared
+type modeline struct {
+	language    string
+	indentStyle string
+	indentSize  int
+}
+
+// parseModeline finds a Vim or Emacs modeline in the first or last lines
+func parseModeline(content []byte) modeline {
+	text := string(content)
+	lines := strings.Split(text, "\n")
+
+	candidates := lines
+	if len(lines) > modelineSearchLines*2 {
+		candidates = append(append([]string{}, lines[:modelineSearchLines]...), lines[len(lines)-modelineSearchLines:]...)
+	}
+
+	var result modeline
+	for i, line := range candidates {
+		// Emacs only honours the first line (second after a shebang)
+		if i < 2 {
+			if m := parseEmacsModeline(line); m.language != "" || m.indentStyle != "" {
+				mergeModeline(&result, m)
+				continue
+			}
+		}
+		mergeModeline(&result, parseVimModeline(line))
+	}
+
+	return result
+}
+
+func mergeModeline(into *modeline, m modeline) {
+	if m.language != "" {
+		into.language = m.language
+	}
+	if m.indentStyle != "" {
+		into.indentStyle = m.indentStyle
+	}
+	if m.indentSize > 0 {
+		into.indentSize = m.indentSize
+	}
+}
+
+func parseVimModeline(line string) modeline {
+	match := vimModelinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return modeline{}
+	}
+
+	var result modeline
+	var tabstop, shiftwidth int
+	for _, option := range vimOptionPattern.FindAllStringSubmatch(match[1], -1) {
+		switch option[1] {
+		case "ft", "filetype", "syn", "syntax":
+			result.language = resolveLanguageAlias(option[2])
+		case "ts", "tabstop":
+			tabstop, _ = strconv.Atoi(option[2])
+		case "sw", "shiftwidth", "sts", "softtabstop":
+			if n, _ := strconv.Atoi(option[2]); n > 0 {
+				shiftwidth = n
+			}
+		case "et", "expandtab":
+			result.indentStyle = IndentStyleSpace
+		case "noet", "noexpandtab":
+			result.indentStyle = IndentStyleTab
+		}
+	}
+
+	result.indentSize = shiftwidth
+	if result.indentSize == 0 {
+		result.indentSize = tabstop
+	}
+	return result
+}
+
+func parseEmacsModeline(line string) modeline {
+	match := emacsModelinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return modeline{}
+	}
+
+	body := match[1]
+	if !strings.Contains(body, ":") {
+		// -*- python -*- is shorthand for the mode
+		return modeline{language: resolveEmacsMode(body)}
+	}
+
+	var result modeline
+	for _, setting := range strings.Split(body, ";") {
+		key, value, ok := strings.Cut(setting, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(strings.ToLower(key)), strings.TrimSpace(value)
+		switch key {
+		case "mode":
+			result.language = resolveEmacsMode(value)
+		case "indent-tabs-mode":
+			if value == "nil" {
+				result.indentStyle = IndentStyleSpace
+			} else {
+				result.indentStyle = IndentStyleTab
+			}
+		case "tab-width", "c-basic-offset", "python-indent-offset", "js-indent-level", "sh-basic-offset":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				result.indentSize = n
+			}
+		}
+	}
+	return result
+}
+
+// resolveEmacsMode maps "ruby-mode" / "ruby" to a registry ID
+func resolveEmacsMode(mode string) string {
+	mode = strings.TrimSuffix(strings.TrimSpace(strings.ToLower(mode)), "-mode")
+	return resolveLanguageAlias(mode)
+}
@@ -0,0 +1,211 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Jupyter notebook flattening
+// Why: .ipynb files are JSON with base64 images and HTML tables embedded.
+// Flattening them to linear source keeps the code and prose at a fraction
+// of the tokens.
+
+const DefaultNotebookOutputLines = 20
+
+type notebook struct {
+	Cells    []notebookCell   `json:"cells"`
+	Metadata notebookMetadata `json:"metadata"`
+}
+
+type notebookMetadata struct {
+	KernelSpec struct {
+		Name     string `json:"name"`
+		Language string `json:"language"`
+	} `json:"kernelspec"`
+	LanguageInfo struct {
+		Name string `json:"name"`
+	} `json:"language_info"`
+}
+
+type notebookCell struct {
+	CellType       string           `json:"cell_type"`
+	Source         notebookText     `json:"source"`
+	ExecutionCount *int             `json:"execution_count"`
+	Outputs        []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string                  `json:"output_type"`
+	Text       notebookText            `json:"text"`
+	Data       map[string]notebookText `json:"data"`
+	EName      string                  `json:"ename"`
+	EValue     string                  `json:"evalue"`
+}
+
+// notebookText handles nbformat's "string or list of strings" fields
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = notebookText(strings.Join(lines, ""))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		// Non-text payloads (e.g. application/json outputs) are ignored
+		*t = ""
+		return nil
+	}
+	*t = notebookText(s)
+	return nil
+}
+
+func isNotebookFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".ipynb"
+}
+
+// flattenNotebook converts notebook JSON into linear source code
+// Returns the flattened source and the kernel language
+func flattenNotebook(content []byte, opts ScanOptions) (string, string, error) {
+	var nb notebook
+	if err := json.Unmarshal(content, &nb); err != nil {
+		return "", "", fmt.Errorf("invalid notebook: %w", err)
+	}
+
+	language := notebookLanguage(nb.Metadata)
+	prefix := lineCommentPrefix(language)
+
+	maxOutputLines := opts.NotebookOutputLines
+	if maxOutputLines <= 0 {
+		maxOutputLines = DefaultNotebookOutputLines
+	}
+
+	var builder strings.Builder
+	codeCell := 0
+
+	for i, cell := range nb.Cells {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+
+		source := strings.TrimRight(string(cell.Source), "\n")
+
+		switch cell.CellType {
+		case "code":
+			codeCell++
+			number := codeCell
+			if cell.ExecutionCount != nil {
+				number = *cell.ExecutionCount
+			}
+
+			builder.WriteString(fmt.Sprintf("%s In[%d]:\n", prefix, number))
+			if source != "" {
+				builder.WriteString(source + "\n")
+			}
+
+			if opts.NotebookOutputs {
+				if text := notebookOutputText(cell.Outputs); text != "" {
+					builder.WriteString(fmt.Sprintf("\n%s Out[%d]:\n", prefix, number))
+					builder.WriteString(commentLines(truncateLines(text, maxOutputLines), prefix))
+				}
+			}
+
+		case "markdown", "raw":
+			if source != "" {
+				builder.WriteString(commentLines(source, prefix))
+			}
+		}
+	}
+
+	return builder.String(), language, nil
+}
+
+// notebookLanguage reads the kernel language from notebook metadata
+func notebookLanguage(meta notebookMetadata) string {
+	lang := meta.LanguageInfo.Name
+	if lang == "" {
+		lang = meta.KernelSpec.Language
+	}
+	if lang == "" {
+		lang = meta.KernelSpec.Name
+	}
+
+	lang = strings.ToLower(lang)
+	switch {
+	case lang == "":
+		return "python" // nbformat default kernel
+	case strings.HasPrefix(lang, "python"):
+		return "python"
+	case lang == "ir":
+		return "r"
+	case lang == "c++" || strings.HasPrefix(lang, "xcpp"):
+		return "cpp"
+	case lang == "bash" || lang == "sh":
+		return "bash"
+	case lang == "octave":
+		return "matlab"
+	case lang == "c#":
+		return "csharp"
+	case lang == "f#":
+		return "fsharp"
+	}
+	return lang
+}
+
+// notebookOutputText collects the text parts of a cell's outputs
+// Images, HTML and widget payloads are dropped
+func notebookOutputText(outputs []notebookOutput) string {
+	var parts []string
+
+	for _, out := range outputs {
+		switch out.OutputType {
+		case "stream":
+			parts = append(parts, strings.TrimRight(string(out.Text), "\n"))
+		case "execute_result", "display_data":
+			if text, ok := out.Data["text/plain"]; ok {
+				parts = append(parts, strings.TrimRight(string(text), "\n"))
+			}
+		case "error":
+			parts = append(parts, fmt.Sprintf("%s: %s", out.EName, out.EValue))
+		}
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// truncateLines keeps the first max lines and notes how many were dropped
+func truncateLines(text string, max int) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) <= max {
+		return text
+	}
+
+	kept := append([]string{}, lines[:max]...)
+	kept = append(kept, fmt.Sprintf("... [%d more lines]", len(lines)-max))
+	return strings.Join(kept, "\n")
+}
+
+// commentLines prefixes every line with a line comment marker
+func commentLines(text, prefix string) string {
+	var builder strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			builder.WriteString(prefix + "\n")
+		} else {
+			builder.WriteString(prefix + " " + line + "\n")
+		}
+	}
+	return builder.String()
+}
+
+// lineCommentPrefix returns the single-line comment marker for a language
+func lineCommentPrefix(language string) string {
+	if lang, ok := LookupLanguage(language); ok && len(lang.LineComments) > 0 {
+		return lang.LineComments[0]
+	}
+	return "#"
+}
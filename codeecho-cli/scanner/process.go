@@ -1,13 +1,122 @@
 package scanner
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"os"
 	"regexp"
 	"strings"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
 )
 
-func processFileContent(content, language string, opts ScanOptions) string {
+// loadFileContent reads a file and fills in the content-derived fields
+// Why: Streaming and analysis scanners share the same read → detect → process pipeline
+func loadFileContent(fileInfo *FileInfo, opts ScanOptions) error {
+	// Unknown extensions: sniff a sample before reading the whole file
+	// Why: Avoids loading large binaries just to discard them
+	if !fileInfo.IsText {
+		sample, err := readSample(fileInfo.Path)
+		if err != nil {
+			return err
+		}
+		if !isTextContent(sample) {
+			return nil
+		}
+		fileInfo.IsText = true
+	}
+
+	content, err := os.ReadFile(fileInfo.Path)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(content)
+	fileInfo.SHA256 = hex.EncodeToString(sum[:])
+
+	// Transcode to UTF-8 so every later step sees the same encoding
+	fileInfo.Encoding = detectEncoding(content)
+	if fileInfo.Encoding == "" {
+		// Text by extension but binary-looking bytes: keep it as UTF-8
+		fileInfo.Encoding = EncodingUTF8
+	}
+	content, err = transcodeToUTF8(content, fileInfo.Encoding)
+	if err != nil {
+		return err
+	}
+
+	// Modelines are explicit, so they override editorconfig and the path
+	mode := parseModeline(content)
+	if mode.indentStyle != "" {
+		fileInfo.IndentStyle = mode.indentStyle
+	}
+	if mode.indentSize > 0 {
+		fileInfo.IndentSize = mode.indentSize
+	}
+
+	if mode.language != "" {
+		fileInfo.Language = mode.language
+		fileInfo.DetectionMethod = DetectionModeline
+		fileInfo.LanguageConfidence = confidenceModeline
+	} else if fileInfo.LanguageConfidence < confidenceExtension {
+		// Try content-based detection if the language is unknown or ambiguous
+		if lang, method, confidence := detectLanguageFromContent(fileInfo.Path, content); lang != "" {
+			fileInfo.Language = lang
+			fileInfo.DetectionMethod = method
+			fileInfo.LanguageConfidence = confidence
+		}
+	}
+
+	text := string(content)
+	fileInfo.LineEnding = detectLineEnding(text)
+
+	// Content-based classification (generator headers, minification)
+	// .gitattributes decisions are final, so only unexplained "source" is refined
+	if fileInfo.Category == CategorySource && fileInfo.CategoryReason == "" {
+		fileInfo.Category, fileInfo.CategoryReason = classifyContent(fileInfo.Path, text)
+	}
+	if categoryExcluded(fileInfo.Category, opts) {
+		// The scanner drops the file - no point processing it
+		return nil
+	}
+
+	// Flatten notebooks to linear source in the kernel language
+	if isNotebookFile(fileInfo.Path) {
+		flattened, language, err := flattenNotebook(content, opts)
+		if err != nil {
+			return err
+		}
+		text = flattened
+		fileInfo.Language = language
+		fileInfo.Flattened = true
+	}
+
+	// Replace large data files with a representative sample
+	if opts.SampleDataFiles {
+		if sampled, ok := sampleDataFile(fileInfo.Path, text, opts); ok {
+			fileInfo.Sampled = true
+			fileInfo.OriginalLineCount = utils.CountLines(text)
+			text = sampled
+		}
+	}
+
+	// Why: XML cannot carry these characters even escaped, and JSON turns
+	// invalid UTF-8 into U+FFFD; dropping them before hashing keeps
+	// content_sha256 true of what every format writes
+	processedContent := utils.StripInvalidXMLChars(processFileContent(text, fileInfo, opts))
+	fileInfo.Content = processedContent
+	contentSum := sha256.Sum256([]byte(processedContent))
+	fileInfo.ContentSHA256 = hex.EncodeToString(contentSum[:])
+	fileInfo.LineCount = utils.CountLines(processedContent)
+	fileInfo.TokenCount = utils.EstimateTokens(processedContent)
+
+	return nil
+}
+
+func processFileContent(content string, fileInfo *FileInfo, opts ScanOptions) string {
 	processed := content
+	language := fileInfo.Language
 
 	if opts.RemoveComments {
 		processed = stripComments(processed, language)
@@ -16,41 +125,20 @@ func processFileContent(content, language string, opts ScanOptions) string {
 		processed = stripEmptyLines(processed)
 	}
 	if opts.CompressCode {
-		processed = compressWhitespace(processed, language)
+		processed = compressWhitespace(processed, language, fileInfo.IndentSize)
 	}
 
 	return processed
 }
 
 // stripComments removes comments based on file language
+// Comment syntax comes from the language registry
 func stripComments(content, language string) string {
-	switch language {
-	case "go", "javascript", "typescript", "java", "cpp", "c", "rust", "php":
-		// Remove single-line comments //
-		re1 := regexp.MustCompile(`//.*$`)
-		content = re1.ReplaceAllString(content, "")
-
-		// Remove multi-line comments /* */
-		re2 := regexp.MustCompile(`/\*[\s\S]*?\*/`)
-		content = re2.ReplaceAllString(content, "")
-
-	case "python", "ruby":
-		// Remove # comments
-		re := regexp.MustCompile(`#.*$`)
-		content = re.ReplaceAllString(content, "")
-
-	case "html", "xml":
-		// Remove HTML/XML comments <!-- -->
-		re := regexp.MustCompile(`<!--[\s\S]*?-->`)
-		content = re.ReplaceAllString(content, "")
-
-	case "css":
-		// Remove CSS comments /* */
-		re := regexp.MustCompile(`/\*[\s\S]*?\*/`)
-		content = re.ReplaceAllString(content, "")
+	pattern := commentPattern(language)
+	if pattern == nil {
+		return content
 	}
-
-	return content
+	return pattern.ReplaceAllString(content, "")
 }
 
 // stripEmptyLines removes empty lines from content
@@ -68,7 +156,8 @@ func stripEmptyLines(content string) string {
 }
 
 // compressWhitespace removes unnecessary whitespace
-func compressWhitespace(content, language string) string {
+// indentSize comes from .editorconfig or a modeline; 0 means infer it
+func compressWhitespace(content, language string, indentSize int) string {
 	switch language {
 	case "json":
 		// For JSON, we can actually minify it properly
@@ -80,14 +169,22 @@ func compressWhitespace(content, language string) string {
 		}
 	case "javascript", "css":
 		// Basic whitespace compression for JS/CSS
-		// Remove extra spaces and tabs (but preserve single spaces)
-		re := regexp.MustCompile(`[ \t]+`)
-		content = re.ReplaceAllString(content, " ")
+		// Collapse runs of spaces and tabs after the indentation
+		re := regexp.MustCompile(`(\S)[ \t]+`)
+		content = re.ReplaceAllString(content, "$1 ")
+	}
+
+	// Re-indent to one tab per level so nesting survives compression
+	if canReindent(language) {
+		if indentSize <= 0 {
+			_, indentSize = inferIndentation(content)
+		}
+		content = reindent(content, indentSize)
 	}
 
 	// Generic whitespace compression
 	// Remove trailing whitespace from each line
-	re := regexp.MustCompile(`[ \t]+$`)
+	re := regexp.MustCompile(`(?m)[ \t]+$`)
 	content = re.ReplaceAllString(content, "")
 
 	return content
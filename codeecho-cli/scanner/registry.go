@@ -0,0 +1,361 @@
+package scanner
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Language registry
+// Why: Extension maps used to live in detectLanguage, isTextExtension and
+// cmd/doc.go, each with different coverage and names. One embedded table
+// drives detection, comment stripping, fence tags and doc stats.
+
+//go:embed languages.yml
+var embeddedLanguages []byte
+
+// Language types (same buckets as linguist)
+const (
+	LanguageTypeProgramming = "programming"
+	LanguageTypeMarkup      = "markup"
+	LanguageTypeData        = "data"
+	LanguageTypeProse       = "prose"
+)
+
+// Language describes one entry in the language registry
+// The same shape is accepted under `languages:` in .codeecho.yaml
+type Language struct {
+	ID            string     `yaml:"id" json:"id"`
+	Name          string     `yaml:"name" json:"name"`
+	Type          string     `yaml:"type" json:"type"`
+	Aliases       []string   `yaml:"aliases" json:"aliases"`
+	Extensions    []string   `yaml:"extensions" json:"extensions"`
+	Filenames     []string   `yaml:"filenames" json:"filenames"`
+	Interpreters  []string   `yaml:"interpreters" json:"interpreters"`
+	LineComments  []string   `yaml:"line_comments" json:"line_comments"`
+	BlockComments [][]string `yaml:"block_comments" json:"block_comments"`
+	Fence         string     `yaml:"fence" json:"fence"`
+}
+
+// languageRegistry indexes languages by extension, filename and interpreter
+// Index slices keep registration order so the first claimant wins
+type languageRegistry struct {
+	mu            sync.RWMutex
+	languages     map[string]*Language
+	byExtension   map[string][]*Language
+	byFilename    map[string][]*Language
+	byInterpreter map[string][]*Language
+	byAlias       map[string]*Language
+	comments      map[string]*regexp.Regexp
+
+	// Extensions claimed from config skip disambiguation heuristics
+	userExtensions map[string]bool
+}
+
+var (
+	registryOnce sync.Once
+	registry     *languageRegistry
+)
+
+// defaultRegistry loads the embedded registry on first use
+func defaultRegistry() *languageRegistry {
+	registryOnce.Do(func() {
+		var defs []Language
+		if err := yaml.Unmarshal(embeddedLanguages, &defs); err != nil {
+			// The file is compiled in, so this is a build problem
+			panic(fmt.Sprintf("invalid embedded languages.yml: %v", err))
+		}
+
+		registry = &languageRegistry{
+			languages:      make(map[string]*Language),
+			byExtension:    make(map[string][]*Language),
+			byFilename:     make(map[string][]*Language),
+			byInterpreter:  make(map[string][]*Language),
+			byAlias:        make(map[string]*Language),
+			comments:       make(map[string]*regexp.Regexp),
+			userExtensions: make(map[string]bool),
+		}
+		for i := range defs {
+			if err := registry.add(defs[i], false); err != nil {
+				panic(fmt.Sprintf("invalid embedded languages.yml: %v", err))
+			}
+		}
+	})
+	return registry
+}
+
+// RegisterLanguages adds or extends registry entries (from config)
+// A new ID creates a language; an existing ID gets the new extensions,
+// filenames and interpreters, and any non-empty fields replace the defaults.
+// User entries take priority over built-ins for the extensions they list.
+func RegisterLanguages(defs []Language) error {
+	r := defaultRegistry()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, def := range defs {
+		if err := r.add(def, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add must be called with the write lock held (or during initial load)
+func (r *languageRegistry) add(def Language, priority bool) error {
+	id := strings.ToLower(strings.TrimSpace(def.ID))
+	if id == "" {
+		return fmt.Errorf("language %q: id is required", def.Name)
+	}
+	for _, pair := range def.BlockComments {
+		if len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+			return fmt.Errorf("language %q: block_comments entries must be [open, close] pairs", id)
+		}
+	}
+	switch def.Type {
+	case "", LanguageTypeProgramming, LanguageTypeMarkup, LanguageTypeData, LanguageTypeProse:
+	default:
+		return fmt.Errorf("language %q: invalid type %q (must be programming, markup, data or prose)", id, def.Type)
+	}
+
+	lang, exists := r.languages[id]
+	if !exists {
+		lang = &Language{ID: id, Name: id, Type: LanguageTypeProgramming}
+		r.languages[id] = lang
+	}
+
+	if def.Name != "" {
+		lang.Name = def.Name
+	}
+	if def.Type != "" {
+		lang.Type = def.Type
+	}
+	if def.Fence != "" {
+		lang.Fence = def.Fence
+	}
+	if len(def.LineComments) > 0 || len(def.BlockComments) > 0 {
+		lang.LineComments = def.LineComments
+		lang.BlockComments = def.BlockComments
+		delete(r.comments, id)
+	}
+
+	for _, alias := range def.Aliases {
+		lang.Aliases = append(lang.Aliases, alias)
+		r.byAlias[strings.ToLower(alias)] = lang
+	}
+	for _, ext := range def.Extensions {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		lang.Extensions = append(lang.Extensions, ext)
+		r.byExtension[ext] = indexLanguage(r.byExtension[ext], lang, priority)
+		if priority {
+			r.userExtensions[ext] = true
+		}
+	}
+	for _, name := range def.Filenames {
+		lang.Filenames = append(lang.Filenames, name)
+		key := strings.ToLower(name)
+		r.byFilename[key] = indexLanguage(r.byFilename[key], lang, priority)
+	}
+	for _, interpreter := range def.Interpreters {
+		lang.Interpreters = append(lang.Interpreters, interpreter)
+		r.byInterpreter[interpreter] = indexLanguage(r.byInterpreter[interpreter], lang, priority)
+	}
+
+	return nil
+}
+
+// indexLanguage adds lang to an index slice, at the front when it has priority
+func indexLanguage(list []*Language, lang *Language, priority bool) []*Language {
+	for i, existing := range list {
+		if existing == lang {
+			if !priority {
+				return list
+			}
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if priority {
+		return append([]*Language{lang}, list...)
+	}
+	return append(list, lang)
+}
+
+// LookupLanguage returns the registry entry for a language ID
+func LookupLanguage(id string) (Language, bool) {
+	r := defaultRegistry()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lang, ok := r.languages[strings.ToLower(id)]
+	if !ok {
+		return Language{}, false
+	}
+	return *lang, true
+}
+
+// LanguageDisplayName returns the human-readable name for a language ID
+func LanguageDisplayName(id string) string {
+	if lang, ok := LookupLanguage(id); ok {
+		return lang.Name
+	}
+	return id
+}
+
+// LanguageFenceTag returns the Markdown code fence info string for a language ID
+func LanguageFenceTag(id string) string {
+	lang, ok := LookupLanguage(id)
+	if !ok {
+		return strings.ToLower(id)
+	}
+	if lang.Fence != "" {
+		return lang.Fence
+	}
+	return lang.ID
+}
+
+// resolveLanguageAlias maps a free-form language name (Vim filetype, Emacs
+// mode, fence tag) to a registry ID
+// Tries IDs, aliases, display names, then extensions and interpreters
+func resolveLanguageAlias(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+
+	r := defaultRegistry()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if lang, ok := r.languages[name]; ok {
+		return lang.ID
+	}
+	if lang, ok := r.byAlias[name]; ok {
+		return lang.ID
+	}
+	for _, lang := range r.languages {
+		if strings.ToLower(lang.Name) == name {
+			return lang.ID
+		}
+	}
+	if langs := r.byExtension["."+name]; len(langs) > 0 {
+		return langs[0].ID
+	}
+	if langs := r.byInterpreter[name]; len(langs) > 0 {
+		return langs[0].ID
+	}
+	return ""
+}
+
+// languagesForPath returns every language claiming a path, in priority order,
+// along with how they matched (filename or extension) and the matched extension
+// Exact filenames beat extensions; longer extensions (".blade.php") beat
+// shorter ones (".php")
+func languagesForPath(path string) ([]*Language, string, string) {
+	r := defaultRegistry()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	base := strings.ToLower(filepath.Base(path))
+	if langs := r.byFilename[base]; len(langs) > 0 {
+		return langs, DetectionFilename, ""
+	}
+
+	for i := 0; i < len(base); i++ {
+		if base[i] != '.' {
+			continue
+		}
+		ext := base[i:]
+		langs := r.byExtension[ext]
+		if len(langs) == 0 {
+			continue
+		}
+		if r.userExtensions[ext] {
+			// An explicit config claim is not second-guessed
+			return langs[:1], DetectionExtension, ext
+		}
+		return langs, DetectionExtension, ext
+	}
+	return nil, "", ""
+}
+
+// languagesForInterpreter returns the languages run by a shebang interpreter
+func languagesForInterpreter(interpreter string) []*Language {
+	r := defaultRegistry()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if langs := r.byInterpreter[interpreter]; len(langs) > 0 {
+		return langs
+	}
+	// python3.11 -> python
+	return r.byInterpreter[strings.TrimRight(interpreter, "0123456789.")]
+}
+
+// registryHasExtension reports whether any language claims an extension
+func registryHasExtension(ext string) bool {
+	r := defaultRegistry()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.byExtension[strings.ToLower(ext)]) > 0
+}
+
+// registryHasFilename reports whether any language claims an exact filename
+func registryHasFilename(name string) bool {
+	r := defaultRegistry()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.byFilename[strings.ToLower(name)]) > 0
+}
+
+// commentPattern returns a regexp matching the comments of a language
+// Returns nil when the language has no comment syntax
+// Why one alternation: The leftmost match wins, so "/* // */" and
+// "// /* */" are both handled as the comment that actually starts first
+func commentPattern(id string) *regexp.Regexp {
+	r := defaultRegistry()
+
+	r.mu.RLock()
+	pattern, cached := r.comments[id]
+	lang := r.languages[id]
+	r.mu.RUnlock()
+	if cached {
+		return pattern
+	}
+	if lang == nil {
+		return nil
+	}
+
+	var alternatives []string
+	for _, pair := range lang.BlockComments {
+		alternatives = append(alternatives, regexp.QuoteMeta(pair[0])+`[\s\S]*?`+regexp.QuoteMeta(pair[1]))
+	}
+	for _, marker := range lang.LineComments {
+		// Require a line start or whitespace before the marker so that
+		// "http://", "#fff" and "a%b" inside code survive
+		alt := `(?:^|[ \t])` + regexp.QuoteMeta(marker)
+		if last := marker[len(marker)-1]; (last >= 'a' && last <= 'z') || (last >= 'A' && last <= 'Z') {
+			// Word markers (REM, dnl) must not match a longer identifier
+			alt += `\b`
+		}
+		alternatives = append(alternatives, alt+`.*$`)
+	}
+	if len(alternatives) > 0 {
+		pattern = regexp.MustCompile(`(?m)` + strings.Join(alternatives, "|"))
+	}
+
+	r.mu.Lock()
+	r.comments[id] = pattern
+	r.mu.Unlock()
+	return pattern
+}
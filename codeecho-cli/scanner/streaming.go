@@ -15,7 +15,7 @@ type StreamingScanner struct {
 	rootPath    string
 	opts        ScanOptions
 	fileHandler func(*FileInfo) error
-	treeWriter  func([]string) error
+	treeWriter  func(*Tree) error
 
 	// Progress and error tracking
 	progressCallback ProgressCallback
@@ -23,12 +23,21 @@ type StreamingScanner struct {
 
 	stats     *StreamingStats
 	filePaths []string
+	tree      *Tree
+	manifest  *Manifest
 
 	// Timing
 	startTime time.Time
 
 	gitignore *ignore.GitIgnore
 	gitMeta   *GitMetadata
+
+	// Generated/vendored classification and files dropped because of it
+	classifier *fileClassifier
+	skipped    []SkippedFile
+
+	// Indentation policy from .editorconfig files
+	editorConfig *editorConfigResolver
 }
 
 // StreamingStats tracks lightweight counters (not full file data)
@@ -37,7 +46,59 @@ type StreamingStats struct {
 	TotalSize      int64
 	TextFiles      int
 	BinaryFiles    int
+	TotalLines     int
+	TotalTokens    int
 	LanguageCounts map[string]int
+	Languages      map[string]*LanguageStat // Per-language totals, keyed like LanguageCounts
+	Errors         []ScanError              // Problems recorded during the scan, also from GetErrors
+	Skipped        []SkippedFile            // Files left out on purpose, also from GetSkippedFiles
+	ManifestDigest string                   // Manifest digest over the files written, see Manifest
+	Duration       time.Duration            // Time Scan took
+}
+
+// LanguageStat totals the files of one language
+type LanguageStat struct {
+	Files  int
+	Lines  int
+	Tokens int
+	Size   int64
+}
+
+// NewStreamingStats returns empty stats ready for AddFile
+func NewStreamingStats() *StreamingStats {
+	return &StreamingStats{
+		LanguageCounts: make(map[string]int),
+		Languages:      make(map[string]*LanguageStat),
+	}
+}
+
+// AddFile counts a file that was written to the output
+// Why: The scanner is the one place files are counted; writers read the
+// totals in WriteFooter instead of keeping their own copies
+func (s *StreamingStats) AddFile(file *FileInfo) {
+	s.TotalFiles++
+	s.TotalSize += file.Size
+	s.TotalLines += file.LineCount
+	s.TotalTokens += file.TokenCount
+
+	if file.IsText {
+		s.TextFiles++
+	} else {
+		s.BinaryFiles++
+	}
+
+	if file.Language != "" {
+		s.LanguageCounts[file.Language]++
+		lang := s.Languages[file.Language]
+		if lang == nil {
+			lang = &LanguageStat{}
+			s.Languages[file.Language] = lang
+		}
+		lang.Files++
+		lang.Lines += file.LineCount
+		lang.Tokens += file.TokenCount
+		lang.Size += file.Size
+	}
 }
 
 // NewStreamingScanner creates a scanner that calls fileHandler for each file
@@ -46,12 +107,26 @@ func NewStreamingScanner(rootPath string, opts ScanOptions, fileHandler func(*Fi
 		rootPath:    rootPath,
 		opts:        opts,
 		fileHandler: fileHandler,
-		stats: &StreamingStats{
-			LanguageCounts: make(map[string]int),
-		},
-		filePaths: []string{},
-		errors:    []ScanError{},
+		stats:       NewStreamingStats(),
+		filePaths:   []string{},
+		tree:        NewTree(rootPath),
+		errors:      []ScanError{},
+		manifest:    NewManifest(),
+	}
+
+	// Load linguist overrides for generated/vendored classification
+	classifier, err := newFileClassifier(rootPath)
+	if err != nil {
+		// Record error but continue - path and content heuristics still apply
+		scanner.errors = append(scanner.errors, ScanError{
+			Path:    filepath.Join(rootPath, ".gitattributes"),
+			Phase:   "gitattributes",
+			Error:   err,
+			Skipped: false,
+		})
 	}
+	scanner.classifier = classifier
+	scanner.editorConfig = newEditorConfigResolver(rootPath)
 
 	// Load Git information if git-aware mode is enabled
 	if opts.GitAware {
@@ -92,7 +167,7 @@ func (s *StreamingScanner) SetProgressCallback(callback ProgressCallback) {
 	s.progressCallback = callback
 }
 
-func (s *StreamingScanner) SetTreeWriter(treeWriter func([]string) error) {
+func (s *StreamingScanner) SetTreeWriter(treeWriter func(*Tree) error) {
 	s.treeWriter = treeWriter
 }
 
@@ -100,6 +175,11 @@ func (s *StreamingScanner) GetFilePaths() []string {
 	return s.filePaths
 }
 
+// GetSkippedFiles returns files left out by --exclude-generated/--exclude-vendored
+func (s *StreamingScanner) GetSkippedFiles() []SkippedFile {
+	return s.skipped
+}
+
 // Get collected errors
 // Why: Return all errors at the end
 func (s *StreamingScanner) GetErrors() []ScanError {
@@ -147,6 +227,14 @@ func (s *StreamingScanner) recordError(path string, phase string, err error, ski
 	}
 }
 
+// excludeFromTree records a skipped directory for the directory tree
+func (s *StreamingScanner) excludeFromTree(path string, reason string) {
+	s.tree.Excluded = append(s.tree.Excluded, SkippedFile{
+		Path:   utils.GetRelativePath(s.rootPath, path),
+		Reason: reason,
+	})
+}
+
 // Update: Enhanced with error tracking
 func (s *StreamingScanner) collectPaths() error {
 	s.reportProgress("collecting", "scanning directories...")
@@ -158,7 +246,11 @@ func (s *StreamingScanner) collectPaths() error {
 		}
 
 		// Skip excluded directories
+		// The tree can list them, so remember them (never the root itself)
 		if d.IsDir() && shouldExcludeDir(d.Name(), s.opts.ExcludeDirs) {
+			if path != s.rootPath {
+				s.excludeFromTree(path, TreeExcludedDir)
+			}
 			return filepath.SkipDir
 		}
 
@@ -167,6 +259,7 @@ func (s *StreamingScanner) collectPaths() error {
 			relativePath := utils.GetRelativePath(s.rootPath, path)
 			if IsIgnoredByGitignore(relativePath, s.gitignore) {
 				if d.IsDir() {
+					s.excludeFromTree(path, TreeIgnoredDir)
 					return filepath.SkipDir
 				}
 				return nil
@@ -177,6 +270,15 @@ func (s *StreamingScanner) collectPaths() error {
 		if !d.IsDir() && shouldIncludeFile(path, s.opts.IncludeExts) {
 			relativePath := utils.GetRelativePath(s.rootPath, path)
 			s.filePaths = append(s.filePaths, relativePath)
+
+			entry := TreeFile{Path: relativePath}
+			if info, err := d.Info(); err == nil {
+				entry.Size = info.Size()
+			}
+			if s.opts.TreeTokens {
+				entry.Tokens = estimateFileTokens(path)
+			}
+			s.tree.Files = append(s.tree.Files, entry)
 		}
 
 		return nil
@@ -198,7 +300,7 @@ func (s *StreamingScanner) Scan() (*StreamingStats, error) {
 		// Write tree immediately after collecting paths
 		if s.treeWriter != nil {
 			s.reportProgress("tree", "writing directory structure...")
-			if err := s.treeWriter(s.filePaths); err != nil {
+			if err := s.treeWriter(s.tree); err != nil {
 				return nil, fmt.Errorf("failed to write tree: %w", err)
 			}
 		}
@@ -240,6 +342,11 @@ func (s *StreamingScanner) Scan() (*StreamingStats, error) {
 		return nil
 	})
 
+	// Why: Writers only see the stats, and reports list what went wrong
+	s.stats.Errors = s.errors
+	s.stats.Skipped = s.skipped
+	s.stats.ManifestDigest = s.manifest.Digest()
+	s.stats.Duration = time.Since(s.startTime)
 	return s.stats, err
 }
 
@@ -255,67 +362,79 @@ func (s *StreamingScanner) processFile(path string, d fs.DirEntry) error {
 	relativePath := utils.GetRelativePath(s.rootPath, path)
 	s.reportProgress("scanning", relativePath)
 
-	language := detectLanguage(path)
+	language, method, confidence := detectLanguage(path)
 	extension := filepath.Ext(path)
 
 	fileInfo := FileInfo{
-		Path:             path,
-		RelativePath:     relativePath,
-		Size:             info.Size(),
-		SizeFormatted:    utils.FormatBytes(info.Size()),
-		ModTime:          info.ModTime().Format(time.RFC3339),
-		ModTimeFormatted: info.ModTime().Format("2006-01-02 15:04:05"),
-		Language:         language,
-		Extension:        extension,
-		IsText:           isTextFile(path, extension),
+		Path:               path,
+		RelativePath:       relativePath,
+		Size:               info.Size(),
+		SizeFormatted:      utils.FormatBytes(info.Size()),
+		ModTime:            info.ModTime().Format(time.RFC3339),
+		ModTimeFormatted:   info.ModTime().Format("2006-01-02 15:04:05"),
+		Language:           language,
+		LanguageConfidence: confidence,
+		DetectionMethod:    method,
+		Extension:          extension,
+		IsText:             isTextFile(path, extension),
+	}
+
+	// Classify by path first so excluded files are never read
+	fileInfo.Category, fileInfo.CategoryReason = s.classifier.classifyPath(relativePath)
+	if categoryExcluded(fileInfo.Category, s.opts) {
+		s.skipFile(&fileInfo)
+		return nil
+	}
+
+	// Project indentation policy (a modeline in the file may override it)
+	fileInfo.IndentStyle, fileInfo.IndentSize, err = s.editorConfig.indentation(path)
+	if err != nil {
+		s.recordError(path, "editorconfig", err, false)
 	}
 
 	// Read and process content if requested
-	if s.opts.IncludeContent && fileInfo.IsText {
-		content, err := os.ReadFile(path)
-		if err != nil {
+	if s.opts.IncludeContent {
+		if err := loadFileContent(&fileInfo, s.opts); err != nil {
 			s.recordError(path, "read", err, true)
 			// Continue with empty content
-		} else {
-			// ENHANCED: Try content-based detection if language unknown
-			if fileInfo.Language == "" {
-				fileInfo.Language = detectLanguageFromContent(path, content)
-			}
-
-			// ENHANCED: Re-check if text using content
-			if !fileInfo.IsText && isTextContent(content) {
-				fileInfo.IsText = true
-			}
+		}
 
-			processedContent := processFileContent(string(content), fileInfo.Language, s.opts)
-			fileInfo.Content = processedContent
-			fileInfo.LineCount = utils.CountLines(processedContent)
+		// Content may reveal a generator header or minified code
+		if categoryExcluded(fileInfo.Category, s.opts) {
+			s.skipFile(&fileInfo)
+			return nil
 		}
 	}
 
-	// Update statistics
-	s.stats.TotalFiles++
-	s.stats.TotalSize += info.Size()
-
-	if fileInfo.IsText {
-		s.stats.TextFiles++
-	} else {
-		s.stats.BinaryFiles++
+	// Describe binary files instead of silently omitting them
+	if err := describeFile(&fileInfo, s.opts); err != nil {
+		s.recordError(path, "binary-metadata", err, false)
 	}
 
-	if fileInfo.Language != "" {
-		s.stats.LanguageCounts[fileInfo.Language]++
-	}
+	s.stats.AddFile(&fileInfo)
 
 	// Call handler immediately, then discard from memory
 	if err := s.fileHandler(&fileInfo); err != nil {
 		s.recordError(path, "write", err, false)
 		return fmt.Errorf("error writing file %s: %w", path, err)
 	}
+	s.manifest.Add(fileInfo.RelativePath, fileInfo.SHA256, fileInfo.ContentSHA256)
 
 	return nil
 }
 
+// Record a file that was deliberately left out
+func (s *StreamingScanner) skipFile(fileInfo *FileInfo) {
+	reason := fileInfo.Category
+	if fileInfo.CategoryReason != "" {
+		reason += " (" + fileInfo.CategoryReason + ")"
+	}
+	s.skipped = append(s.skipped, SkippedFile{
+		Path:   fileInfo.RelativePath,
+		Reason: reason,
+	})
+}
+
 func (s *StreamingScanner) GetGitMetadata() *GitMetadata {
 	return s.gitMeta
 }
@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/NesoHQ/code-echo/codeecho-cli/utils"
+)
+
+// Tree is the repository layout collected before files are processed
+// Why: Files are streamed, so writers need the layout up front to print
+// the directory tree before the first file
+type Tree struct {
+	Root     string        // Name of the scanned directory
+	Files    []TreeFile    // Files that will be scanned, in walk order
+	Excluded []SkippedFile // Directories left out by --exclude-dirs or .gitignore
+}
+
+// TreeFile is one file in a Tree
+type TreeFile struct {
+	Path   string // Relative path
+	Size   int64
+	Tokens int // Estimated from the raw content, only with ScanOptions.TreeTokens
+}
+
+// Reasons a directory is listed in Tree.Excluded
+const (
+	TreeExcludedDir = "excluded"
+	TreeIgnoredDir  = "gitignored"
+)
+
+// NewTree starts an empty tree for the directory at rootPath
+func NewTree(rootPath string) *Tree {
+	return &Tree{Root: filepath.Base(rootPath)}
+}
+
+// TreeFromFiles builds a tree from already scanned files
+func TreeFromFiles(rootPath string, files []FileInfo) *Tree {
+	tree := NewTree(rootPath)
+	for _, file := range files {
+		tree.Files = append(tree.Files, TreeFile{Path: file.RelativePath, Size: file.Size, Tokens: file.TokenCount})
+	}
+	return tree
+}
+
+// Paths returns the relative path of every file
+func (t *Tree) Paths() []string {
+	paths := make([]string, len(t.Files))
+	for i, file := range t.Files {
+		paths[i] = file.Path
+	}
+	return paths
+}
+
+// estimateFileTokens estimates the tokens of a text file before processing
+// Binary and unreadable files count as zero
+func estimateFileTokens(path string) int {
+	if !isTextFile(path, filepath.Ext(path)) {
+		return 0
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	return utils.EstimateTokens(string(content))
+}
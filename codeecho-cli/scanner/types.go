@@ -10,8 +10,40 @@ type FileInfo struct {
 	Content          string `json:"content,omitempty"`
 	Language         string `json:"language,omitempty"`
 	LineCount        int    `json:"line_count,omitempty"`
+	TokenCount       int    `json:"token_count,omitempty"`
 	Extension        string `json:"extension,omitempty"`
 	IsText           bool   `json:"is_text"`
+
+	// How the language was detected and how sure we are (0-1)
+	LanguageConfidence float64 `json:"language_confidence,omitempty"`
+	DetectionMethod    string  `json:"detection_method,omitempty"`
+
+	// Set when the content is a sample of a larger data file
+	Sampled           bool `json:"sampled,omitempty"`
+	OriginalLineCount int  `json:"original_line_count,omitempty"`
+
+	// Set when a notebook's JSON was flattened to linear source
+	Flattened bool `json:"flattened,omitempty"`
+
+	// Classification: source, generated or vendored
+	Category       string `json:"category,omitempty"`
+	CategoryReason string `json:"category_reason,omitempty"`
+
+	// Source encoding (content is always transcoded to UTF-8)
+	Encoding   string `json:"encoding,omitempty"`
+	LineEnding string `json:"line_ending,omitempty"`
+
+	// Indentation from .editorconfig or a Vim/Emacs modeline
+	IndentStyle string `json:"indent_style,omitempty"`
+	IndentSize  int    `json:"indent_size,omitempty"`
+
+	// Content identity and binary file details
+	// SHA256 is of the file on disk, ContentSHA256 of Content as packed
+	// (transcoded and processed), so both staleness and tampering show
+	SHA256        string          `json:"sha256,omitempty"`
+	ContentSHA256 string          `json:"content_sha256,omitempty"`
+	MimeType      string          `json:"mime_type,omitempty"`
+	Binary        *BinaryMetadata `json:"binary,omitempty"`
 }
 
 type ScanResult struct {
@@ -41,6 +73,25 @@ type ScanOptions struct {
 	IncludeExts    []string
 	IncludeContent bool
 	GitAware       bool
+
+	// Data file sampling (CSV, JSONL, logs, SQL dumps, large JSON)
+	SampleDataFiles     bool
+	DataSampleRows      int
+	DataSampleThreshold int64 // bytes
+
+	// Jupyter notebook flattening
+	NotebookOutputs     bool // Keep text outputs of code cells
+	NotebookOutputLines int  // Max lines kept per cell output
+
+	// Extract MIME type, dimensions, archive listings etc. for binary files
+	BinaryMetadata bool
+
+	// Drop files classified as generated (incl. minified, lockfiles) or vendored
+	ExcludeGenerated bool
+	ExcludeVendored  bool
+
+	// Read text files while collecting the tree to estimate their tokens
+	TreeTokens bool
 }
 
 // Progress tracking
@@ -61,6 +112,12 @@ type ScanError struct {
 	Skipped bool   // Was the file skipped or did scan fail?
 }
 
+// Files deliberately left out of the output
+type SkippedFile struct {
+	Path   string // Relative path
+	Reason string // e.g. "generated (lockfile)"
+}
+
 // Complete scan report
 type ScanReport struct {
 	Stats        *StreamingStats
@@ -8,4 +8,21 @@ type OutputOptions struct {
 	RemoveComments       bool
 	RemoveEmptyLines     bool
 	CompressCode         bool
+	SampleDataFiles      bool
+	JSONLShape           string
+	XMLContent           string
+	Template             string // text/template file for the template format
+	SQLiteSymbols        bool
+	ChunkTokens          int
+	ChunkOverlap         int
+
+	// Directory tree rendering
+	TreeStyle    string // unicode, ascii or indent
+	TreeCounts   bool   // File count per directory
+	TreeSizes    bool   // Size per file and directory
+	TreeTokens   bool   // Estimated tokens per file and directory
+	TreeDepth    int    // Deepest level listed, 0 for all
+	TreeExcluded bool   // List skipped directories
+
+	Compression string // gzip or zstd; empty or "none" for plain output
 }
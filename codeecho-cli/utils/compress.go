@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Output compression methods (--compress)
+const (
+	CompressNone = "none"
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+// ValidCompression reports whether method is a supported --compress value
+func ValidCompression(method string) bool {
+	switch method {
+	case "", CompressNone, CompressGzip, CompressZstd:
+		return true
+	}
+	return false
+}
+
+// CompressionFromPath picks the method from a file name suffix
+// ("pack.xml.gz", "pack.json.zst"), or "" when there is none
+func CompressionFromPath(path string) string {
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		return CompressGzip
+	case strings.HasSuffix(path, ".zst"):
+		return CompressZstd
+	}
+	return ""
+}
+
+// CompressionExt is the file name suffix for method
+func CompressionExt(method string) string {
+	switch method {
+	case CompressGzip:
+		return ".gz"
+	case CompressZstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// TrimCompressionExt removes a .gz or .zst suffix
+func TrimCompressionExt(path string) string {
+	return strings.TrimSuffix(path, CompressionExt(CompressionFromPath(path)))
+}
+
+// NewCompressor wraps w so what is written to it is compressed
+// Close flushes the compressed stream but leaves w open
+func NewCompressor(w io.Writer, method string) (io.WriteCloser, error) {
+	switch method {
+	case CompressGzip:
+		return gzip.NewWriter(w), nil
+	case CompressZstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("unsupported compression: %q (use gzip or zstd)", method)
+}
+
+// Magic numbers that start each compressed stream
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Decompress returns data decompressed when it is gzip or zstd, and as is
+// otherwise
+// Why: The magic number, not the file name, decides, so a renamed or
+// piped pack still reads
+func Decompress(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip data: %w", err)
+		}
+		defer reader.Close()
+		out, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress gzip data: %w", err)
+		}
+		return out, nil
+	case bytes.HasPrefix(data, zstdMagic):
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		out, err := decoder.DecodeAll(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress zstd data: %w", err)
+		}
+		return out, nil
+	}
+	return data, nil
+}
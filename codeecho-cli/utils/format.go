@@ -39,6 +39,23 @@ func CountLines(content string) int {
 	return lines
 }
 
+// StripInvalidXMLChars drops characters XML 1.0 doesn't allow anywhere,
+// even escaped: most C0 controls, U+FFFE and U+FFFF
+// Invalid UTF-8 becomes U+FFFD (strings.Map does this for us)
+func StripInvalidXMLChars(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			return r
+		case r >= 0x20 && r <= 0xD7FF,
+			r >= 0xE000 && r <= 0xFFFD,
+			r >= 0x10000 && r <= 0x10FFFF:
+			return r
+		}
+		return -1
+	}, s)
+}
+
 // Format duration human-readable
 func FormatDuration(d time.Duration) string {
 	if d < time.Second {
@@ -50,7 +67,7 @@ func FormatDuration(d time.Duration) string {
 	if d < time.Hour {
 		minutes := int(d.Minutes())
 		seconds := int(d.Seconds()) % 60
-		return fmt.Sprintf("%dh %dm", minutes, seconds)
+		return fmt.Sprintf("%dm %ds", minutes, seconds)
 	}
 	hours := int(d.Hours())
 	minutes := int(d.Minutes()) % 60
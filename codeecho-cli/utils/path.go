@@ -31,8 +31,24 @@ func GenerateAutoFilename(repoPath, format string, opts types.OutputOptions) str
 	switch format {
 	case "json":
 		ext = ".json"
+	case "jsonl":
+		ext = ".jsonl"
+	case "chunks":
+		ext = ".chunks.jsonl"
 	case "markdown", "md":
 		ext = ".md"
+	case "sqlite":
+		ext = ".sqlite"
+	case "html":
+		ext = ".html"
+	case "text", "txt":
+		ext = ".txt"
+	case "template":
+		// "report.md.tmpl" produces .md; a bare "pack.tmpl" produces .txt
+		ext = filepath.Ext(strings.TrimSuffix(filepath.Base(opts.Template), ".tmpl"))
+		if ext == "" {
+			ext = ".txt"
+		}
 	default:
 		ext = ".xml"
 	}
@@ -56,7 +72,7 @@ func GenerateAutoFilename(repoPath, format string, opts types.OutputOptions) str
 	if len(suffix) > 0 {
 		filename += "-" + strings.Join(suffix, "-")
 	}
-	filename += "-" + timestamp + ext
+	filename += "-" + timestamp + ext + CompressionExt(opts.Compression)
 
 	return filename
 }